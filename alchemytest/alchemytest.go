@@ -0,0 +1,143 @@
+package alchemytest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/wjiec/alchemy"
+)
+
+// bufferSize is the size of the buffers of the in-memory listeners.
+const bufferSize = 1 << 20
+
+// Host is the host of the in-memory HTTP server used in the URLs.
+const Host = "alchemytest"
+
+// Server is an App started on in-memory listeners for tests.
+type Server struct {
+	// App is the started App.
+	App *alchemy.App
+
+	// Conn is a gRPC client connection to the App's gRPC server.
+	Conn *grpc.ClientConn
+
+	// Client is an HTTP client whose requests are sent to the App's HTTP server
+	// regardless of the host of the URLs.
+	Client *http.Client
+}
+
+// URL returns the URL of the path on the App's HTTP server, e.g. "/v1/books".
+func (s *Server) URL(path string) string {
+	return "http://" + Host + path
+}
+
+// Start creates an App with both HTTP and gRPC servers on in-memory listeners,
+// starts it and waits until it is ready. The App is stopped and the clients are
+// closed when the test finishes.
+func Start(t testing.TB, options ...Option) *Server {
+	t.Helper()
+
+	cfg := config{args: []string{}}
+	for _, applyOption := range options {
+		applyOption(&cfg)
+	}
+
+	httpListener := bufconn.Listen(bufferSize)
+	grpcListener := bufconn.Listen(bufferSize)
+
+	appOptions := []alchemy.AppOption{
+		alchemy.WithHttpServer(alchemy.NewListenerAddr(httpListener), cfg.httpOptions...),
+		alchemy.WithGrpcServer(alchemy.NewListenerAddr(grpcListener), cfg.grpcOptions...),
+		alchemy.WithBeforeStart(func(ctx context.Context, root *cobra.Command) error {
+			root.SetArgs(cfg.args)
+			return nil
+		}),
+	}
+	app, err := alchemy.New(t.Name(), append(appOptions, cfg.appOptions...)...)
+	if err != nil {
+		t.Fatalf("alchemytest: create app: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- app.Start(context.Background()) }()
+	t.Cleanup(func() {
+		app.Stop()
+		if err := <-done; err != nil {
+			t.Errorf("alchemytest: app stopped with error: %v", err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err = app.WaitReady(ctx); err != nil {
+		t.Fatalf("alchemytest: wait app ready: %v", err)
+	}
+
+	conn, err := grpc.NewClient("passthrough:///"+Host,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return grpcListener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("alchemytest: create grpc client: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return httpListener.DialContext(ctx)
+		},
+	}
+	t.Cleanup(transport.CloseIdleConnections)
+
+	return &Server{App: app, Conn: conn, Client: &http.Client{Transport: transport}}
+}
+
+// Option used to configure the App started by Start.
+type Option func(cfg *config)
+
+// config represents the configuration of the App started by Start.
+type config struct {
+	args        []string
+	appOptions  []alchemy.AppOption
+	httpOptions []alchemy.HttpOption
+	grpcOptions []alchemy.GrpcOption
+}
+
+// WithAppOptions adds the options of the App, which should not configure the servers.
+func WithAppOptions(options ...alchemy.AppOption) Option {
+	return func(cfg *config) {
+		cfg.appOptions = append(cfg.appOptions, options...)
+	}
+}
+
+// WithHttpOptions adds the options of the App's HTTP server.
+func WithHttpOptions(options ...alchemy.HttpOption) Option {
+	return func(cfg *config) {
+		cfg.httpOptions = append(cfg.httpOptions, options...)
+	}
+}
+
+// WithGrpcOptions adds the options of the App's gRPC server.
+func WithGrpcOptions(options ...alchemy.GrpcOption) Option {
+	return func(cfg *config) {
+		cfg.grpcOptions = append(cfg.grpcOptions, options...)
+	}
+}
+
+// WithArgs configures the command line arguments of the App, no argument is
+// passed by default.
+func WithArgs(args ...string) Option {
+	return func(cfg *config) {
+		cfg.args = args
+	}
+}
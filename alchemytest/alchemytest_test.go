@@ -0,0 +1,47 @@
+package alchemytest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/wjiec/alchemy"
+)
+
+func TestStart(t *testing.T) {
+	s := Start(t,
+		WithHttpOptions(alchemy.HttpWithHealthEndpoint("/healthz")),
+		WithGrpcOptions(alchemy.GrpcWithHealthService()),
+	)
+
+	assert.True(t, s.App.Healthy())
+
+	resp, err := grpc_health_v1.NewHealthClient(s.Conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	httpResp, err := s.Client.Get(s.URL("/healthz"))
+	require.NoError(t, err)
+	defer func() { _ = httpResp.Body.Close() }()
+
+	body, err := io.ReadAll(httpResp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, httpResp.StatusCode)
+	assert.Equal(t, "ok", string(body))
+}
+
+func TestWithAppOptions(t *testing.T) {
+	var stopped bool
+	t.Run("stop", func(t *testing.T) {
+		Start(t, WithAppOptions(alchemy.WithOnShutdown(func(ctx context.Context) error {
+			stopped = true
+			return nil
+		})))
+	})
+	assert.True(t, stopped)
+}
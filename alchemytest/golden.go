@@ -0,0 +1,275 @@
+package alchemytest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// updateGolden rewrites the golden files with the actual output instead of comparing them.
+var updateGolden = flag.Bool("update-golden", false, "alchemytest: update the golden files")
+
+// Replay sends each request recorded in the files matched by pattern, e.g.
+// "testdata/*.http", to the App's HTTP server and compares the response with the
+// golden file next to it, which has the same name with the ".golden" extension.
+//
+// Each file is replayed in a subtest named after the file. Run the tests with
+// the -update-golden flag to create or update the golden files.
+func (s *Server) Replay(t *testing.T, pattern string, options ...GoldenOption) {
+	t.Helper()
+
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		t.Fatalf("alchemytest: invalid pattern %q: %v", pattern, err)
+	}
+	if len(paths) == 0 {
+		t.Fatalf("alchemytest: no request file matches %q", pattern)
+	}
+
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		t.Run(name, func(t *testing.T) {
+			req, err := ReadRequest(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := s.Client.Do(req)
+			if err != nil {
+				t.Fatalf("alchemytest: send request: %v", err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+
+			got, err := DumpResponse(resp, options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			AssertGolden(t, strings.TrimSuffix(path, filepath.Ext(path))+".golden", got)
+		})
+	}
+}
+
+// ReadRequest reads an HTTP request to the App's HTTP server from the file.
+//
+// The file starts with the request line, e.g. "POST /v1/books", followed by the
+// header lines, an empty line and the body:
+//
+//	POST /v1/books?parent=shelves/1
+//	Content-Type: application/json
+//
+//	{"title": "Alchemy"}
+func ReadRequest(path string) (*http.Request, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("alchemytest: read request file: %w", err)
+	}
+
+	r := textproto.NewReader(bufio.NewReader(bytes.NewReader(data)))
+	line, err := r.ReadLine()
+	if err != nil {
+		return nil, fmt.Errorf("alchemytest: read request line of %s: %w", path, err)
+	}
+	fields := strings.Fields(line)
+	if len(fields) != 2 && len(fields) != 3 {
+		return nil, fmt.Errorf("alchemytest: malformed request line %q of %s", line, path)
+	}
+
+	header, err := r.ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("alchemytest: read headers of %s: %w", path, err)
+	}
+	body, err := io.ReadAll(r.R)
+	if err != nil {
+		return nil, fmt.Errorf("alchemytest: read body of %s: %w", path, err)
+	}
+
+	req, err := http.NewRequest(fields[0], "http://"+Host+fields[1], bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("alchemytest: invalid request of %s: %w", path, err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	return req, nil
+}
+
+// DumpResponse returns the normalized text of the response to be compared with
+// a golden file, which consists of the status line, the headers sorted by name,
+// an empty line and the body.
+//
+// The Date and Content-Length headers are removed, and the JSON body is indented
+// with the object keys sorted so that the output does not depend on the
+// formatting of the encoder.
+func DumpResponse(resp *http.Response, options ...GoldenOption) ([]byte, error) {
+	g := &golden{ignoredHeaders: []string{"Date", "Content-Length"}}
+	for _, applyOption := range options {
+		applyOption(g)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("alchemytest: read response body: %w", err)
+	}
+	if len(body) != 0 && isJSON(resp.Header.Get("Content-Type")) {
+		if body, err = g.normalizeJSON(body); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "%s %s\n", resp.Proto, resp.Status)
+
+	keys := make([]string, 0, len(resp.Header))
+	for key := range resp.Header {
+		if !slices.Contains(g.ignoredHeaders, key) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		for _, value := range resp.Header[key] {
+			_, _ = fmt.Fprintf(&buf, "%s: %s\n", key, value)
+		}
+	}
+
+	buf.WriteByte('\n')
+	buf.Write(body)
+	if len(body) != 0 && body[len(body)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+
+	out := buf.Bytes()
+	for _, r := range g.replacements {
+		out = r.expr.ReplaceAll(out, []byte(r.replacement))
+	}
+	return out, nil
+}
+
+// AssertGolden compares got with the content of the golden file, or writes got
+// to the golden file if the tests are run with the -update-golden flag.
+func AssertGolden(t testing.TB, path string, got []byte) {
+	t.Helper()
+
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("alchemytest: create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("alchemytest: write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("alchemytest: read golden file (run with -update-golden to create it): %v", err)
+	}
+	assert.Equal(t, string(want), string(got), "golden file %s mismatch, run with -update-golden to update it", path)
+}
+
+// GoldenOption used to configure how the responses are normalized.
+type GoldenOption func(g *golden)
+
+// golden represents the normalization of the responses.
+type golden struct {
+	ignoredHeaders []string
+	jsonFields     []string
+	replacements   []replacement
+}
+
+// replacement replaces the matches of expr in the output.
+type replacement struct {
+	expr        *regexp.Regexp
+	replacement string
+}
+
+// GoldenWithIgnoredHeaders removes the headers from the output in addition to
+// the Date and Content-Length headers.
+func GoldenWithIgnoredHeaders(names ...string) GoldenOption {
+	return func(g *golden) {
+		for _, name := range names {
+			g.ignoredHeaders = append(g.ignoredHeaders, textproto.CanonicalMIMEHeaderKey(name))
+		}
+	}
+}
+
+// GoldenWithJSONFields replaces the values of the fields with the given names
+// at any depth of the JSON body with a "<name>" placeholder, e.g. the IDs and
+// the timestamps. The null values are kept.
+func GoldenWithJSONFields(names ...string) GoldenOption {
+	return func(g *golden) {
+		g.jsonFields = append(g.jsonFields, names...)
+	}
+}
+
+// GoldenWithReplace replaces the matches of the regular expression in the output
+// with the replacement, which may refer to the submatches as in [regexp.Regexp.Expand].
+func GoldenWithReplace(expr, repl string) GoldenOption {
+	re := regexp.MustCompile(expr)
+	return func(g *golden) {
+		g.replacements = append(g.replacements, replacement{expr: re, replacement: repl})
+	}
+}
+
+// normalizeJSON indents the JSON body and replaces the volatile fields.
+func (g *golden) normalizeJSON(body []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("alchemytest: decode JSON body: %w", err)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(g.replaceFields(value)); err != nil {
+		return nil, fmt.Errorf("alchemytest: encode JSON body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// replaceFields replaces the values of the volatile fields in the JSON value.
+func (g *golden) replaceFields(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if field != nil && slices.Contains(g.jsonFields, key) {
+				v[key] = "<" + key + ">"
+			} else {
+				v[key] = g.replaceFields(field)
+			}
+		}
+	case []any:
+		for i, elem := range v {
+			v[i] = g.replaceFields(elem)
+		}
+	}
+	return value
+}
+
+// isJSON reports whether the content type is JSON.
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,153 @@
+package alchemytest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/wjiec/alchemy"
+	"github.com/wjiec/alchemy/internal/testpb"
+)
+
+// MessageRoutes returns the routes of a fake service of messages.
+func MessageRoutes() []alchemy.RouteDesc {
+	return []alchemy.RouteDesc{
+		{
+			HttpMethod:  http.MethodGet,
+			PathPattern: "/v1/messages/{string_value}",
+			Handler: func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				var req testpb.Proto3Message
+				if err := dec(&req); err != nil {
+					return nil, err
+				}
+				if req.GetStringValue() != "m1" {
+					return nil, status.Errorf(codes.NotFound, "message %q not found", req.GetStringValue())
+				}
+				return &testpb.Proto3Message{StringValue: "m1", RepeatedString: []string{"a", "b"}}, nil
+			},
+			PathParameters: []string{"string_value"},
+		},
+		{
+			HttpMethod:  http.MethodPost,
+			PathPattern: "/v1/messages",
+			Handler: func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				var req testpb.Proto3Message
+				if err := dec(&req); err != nil {
+					return nil, err
+				}
+				req.Int64Value = time.Now().UnixNano()
+				return &req, nil
+			},
+		},
+	}
+}
+
+func TestServer_Replay(t *testing.T) {
+	s := Start(t,
+		WithAppOptions(alchemy.WithServiceRegister(func(r alchemy.ServiceRegistrar, srv any) {
+			r.RegisterService(&alchemy.ServiceDesc{
+				GrpcServiceDesc: &grpc.ServiceDesc{ServiceName: "alchemytest.MessageService", HandlerType: (*any)(nil)},
+				Routes:          MessageRoutes(),
+			}, srv)
+		}, any(nil))),
+		WithHttpOptions(alchemy.HttpWithHealthEndpoint("/healthz")),
+	)
+
+	s.Replay(t, "testdata/*.http",
+		GoldenWithJSONFields("int64Value"),
+		GoldenWithReplace(`X-Request-Id: .*`, "X-Request-Id: <id>"),
+	)
+}
+
+func TestReadRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "request.http")
+	require.NoError(t, os.WriteFile(path, []byte("PUT /v1/books/1 HTTP/1.1\nContent-Type: text/plain\nX-Trace: a\n\nbody\n"), 0o644))
+
+	req, err := ReadRequest(path)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPut, req.Method)
+	assert.Equal(t, "http://"+Host+"/v1/books/1", req.URL.String())
+	assert.Equal(t, "text/plain", req.Header.Get("Content-Type"))
+	assert.Equal(t, "a", req.Header.Get("X-Trace"))
+
+	require.NoError(t, os.WriteFile(path, []byte("DELETE /v1/books/1"), 0o644))
+	req, err = ReadRequest(path)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodDelete, req.Method)
+
+	require.NoError(t, os.WriteFile(path, []byte("/v1/books/1\n"), 0o644))
+	_, err = ReadRequest(path)
+	assert.Error(t, err)
+}
+
+func TestDumpResponse(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", "application/json; charset=utf-8")
+	rec.Header().Set("Date", "Mon, 19 Oct 2026 00:00:00 GMT")
+	rec.Header().Set("X-Trace", "t1")
+	rec.Header().Set("X-Version", "v1.2.3")
+	_, _ = rec.WriteString(`{"name":"<b>","id":"8f2c","items":[{"id":1,"createTime":null}],"createTime":"2026-10-19T00:00:00Z"}`)
+
+	got, err := DumpResponse(rec.Result(),
+		GoldenWithIgnoredHeaders("x-trace"),
+		GoldenWithJSONFields("id", "createTime"),
+		GoldenWithReplace(`v\d+\.\d+\.\d+`, "<version>"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"HTTP/1.1 200 OK",
+		"Content-Type: application/json; charset=utf-8",
+		"X-Version: <version>",
+		"",
+		"{",
+		`  "createTime": "<createTime>",`,
+		`  "id": "<id>",`,
+		`  "items": [`,
+		"    {",
+		`      "createTime": null,`,
+		`      "id": "<id>"`,
+		"    }",
+		"  ],",
+		`  "name": "<b>"`,
+		"}",
+		"",
+	}, "\n"), string(got))
+}
+
+func TestAssertGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden", "response.golden")
+
+	*updateGolden = true
+	AssertGolden(t, path, []byte("foobar\n"))
+	*updateGolden = false
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "foobar\n", string(data))
+
+	AssertGolden(t, path, []byte("foobar\n"))
+
+	fake := &FakeTB{TB: t}
+	AssertGolden(fake, path, []byte("barfoo\n"))
+	assert.True(t, fake.failed)
+}
+
+// FakeTB records the failures instead of failing the test.
+type FakeTB struct {
+	testing.TB
+	failed bool
+}
+
+func (tb *FakeTB) Errorf(format string, args ...any) { tb.failed = true }
+func (tb *FakeTB) Fatalf(format string, args ...any) { tb.failed = true }
@@ -2,7 +2,11 @@ package alchemy
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"buf.build/go/protovalidate"
 	"github.com/spf13/cobra"
@@ -18,16 +22,46 @@ type App struct {
 	root     *cobra.Command
 	services []func(ServiceRegistrar)
 
+	registerOnce sync.Once
+	healthy      atomic.Bool
+
+	ready    chan struct{}
+	done     chan struct{}
+	doneOnce sync.Once
+	serveErr error
+	stopping chan struct{}
+	stopOnce sync.Once
+
+	shuttingDown atomic.Bool
+	forcing      chan struct{}
+	forceOnce    sync.Once
+
+	drainDelay      time.Duration
+	shutdownTimeout time.Duration
+
+	components            []*component
+	startedComponents     []*component
+	componentStartTimeout time.Duration
+	componentStopTimeout  time.Duration
+
+	workers []*worker
+
 	httpServer *httpServer
 	grpcServer *grpcServer
 
 	beforeStart       []BeforeStartHook
+	onShutdown        []ShutdownHook
 	unaryInterceptors []UnaryInterceptor
 }
 
 // Start begins the execution of the application by executing the root command
 // in the context provided.
-func (a *App) Start(ctx context.Context) error {
+func (a *App) Start(ctx context.Context) (err error) {
+	defer a.doneOnce.Do(func() {
+		a.serveErr = err
+		close(a.done)
+	})
+
 	for _, hook := range a.beforeStart {
 		if err := hook(ctx, a.root); err != nil {
 			return err
@@ -39,24 +73,64 @@ func (a *App) Start(ctx context.Context) error {
 
 // serve starts the application's concurrent servers and handles their lifecycle,
 // waiting for them to complete. An error group is used to manage lifecycle errors.
+//
+// The components are started before the servers and the workers. The servers are
+// shut down when the context is canceled, or Stop is called, or any of them or the
+// workers fails, then the components are stopped and the shutdown hooks are run.
 func (a *App) serve(ctx context.Context) error {
-	for _, registerService := range a.services {
-		registerService(a)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-a.stopping:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	a.registerServices()
+	if a.httpServer != nil {
+		if err := a.httpServer.checkRoutes(); err != nil {
+			return err
+		}
+	}
+	if err := a.startComponents(ctx); err != nil {
+		return errors.Join(err, a.runShutdownHooks())
 	}
 
 	eg, eCtx := errgroup.WithContext(ctx)
-	if a.grpcServer != nil {
+	servers := a.servers()
+	for _, s := range servers {
 		eg.Go(func() error {
-			return a.grpcServer.Start(eCtx)
+			return s.Start(eCtx)
 		})
 	}
-	if a.httpServer != nil {
+	for _, w := range a.workers {
+		eg.Go(func() error {
+			return w.Run(eCtx)
+		})
+	}
+	if len(servers) != 0 {
+		a.healthy.Store(true)
 		eg.Go(func() error {
-			return a.httpServer.Start(eCtx)
+			<-eCtx.Done()
+			a.shutdown(ctx, servers)
+			return nil
 		})
 	}
+	go a.awaitReady(eCtx, servers)
 
-	return eg.Wait()
+	return errors.Join(eg.Wait(), a.stopComponents(), a.runShutdownHooks())
+}
+
+// registerServices registers the services to the App's servers, the services
+// are only registered once no matter how many times it is called.
+func (a *App) registerServices() {
+	a.registerOnce.Do(func() {
+		for _, registerService := range a.services {
+			registerService(a)
+		}
+	})
 }
 
 // wrapGrpcUnaryInterceptor creates a gRPC UnaryServerInterceptor by wrapping the app's unary interceptors.
@@ -86,7 +160,16 @@ func (a *App) wrapGrpcUnaryInterceptor() grpc.UnaryServerInterceptor {
 
 // New initializes and returns a new *App instance configured with the provided name and options.
 func New(name string, options ...AppOption) (*App, error) {
-	app := &App{name: name}
+	app := &App{
+		name:                  name,
+		ready:                 make(chan struct{}),
+		done:                  make(chan struct{}),
+		stopping:              make(chan struct{}),
+		forcing:               make(chan struct{}),
+		shutdownTimeout:       DefaultGracefulShutdownTimeout,
+		componentStartTimeout: DefaultComponentStartTimeout,
+		componentStopTimeout:  DefaultComponentStopTimeout,
+	}
 	app.root = &cobra.Command{
 		Use: name,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -100,6 +183,12 @@ func New(name string, options ...AppOption) (*App, error) {
 		}
 	}
 
+	components, err := sortComponents(app.components)
+	if err != nil {
+		return nil, err
+	}
+	app.components = components
+
 	app.unaryInterceptors = append(app.unaryInterceptors, DefaultPanicRecoveryInterceptor())
 	app.unaryInterceptors = append(app.unaryInterceptors, DefaultValidateInterceptor())
 
@@ -35,12 +35,14 @@ import (
 func Setup(v1Echo *echov1.EchoService) (*alchemy.App, error) {
 	return alchemy.New("{{ env "PROJECT_NAME" }}",
 {{- if not .WithoutHttp }}
-		alchemy.WithHttpServer("tcp", ":8080"),
+		alchemy.WithHttpServer(alchemy.FlagTCP("http-addr", ":8080")),
 {{- end }}
 {{- if .WithGrpc }}
-		alchemy.WithGrpcServer("tcp", ":8081"),
+		alchemy.WithGrpcServer(alchemy.FlagTCP("grpc-addr", ":8081")),
 {{- end }}
 		alchemy.WithServiceRegister[echov1api.EchoServiceServer](echov1api.RegisterEchoServiceAlchemyServer, v1Echo),
+		alchemy.WithRoutesCommand(),
+		alchemy.WithConfig(alchemy.ConfigWithFile("config.yaml")),
 	)
 }
 
@@ -0,0 +1,100 @@
+package gengo
+
+import (
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+var (
+	contextPackage = protogen.GoImportPath("context")
+	grpcPackage    = protogen.GoImportPath("google.golang.org/grpc")
+	codesPackage   = protogen.GoImportPath("google.golang.org/grpc/codes")
+	statusPackage  = protogen.GoImportPath("google.golang.org/grpc/status")
+)
+
+// genHttpClient generates the HTTP client of the service, which implements the
+// same client interface as the gRPC client generated by protoc-gen-go-grpc.
+//
+// Each method is sent by the first HTTP rule of the method, the methods without
+// HTTP rules and the streaming methods are responded with Unimplemented.
+func genHttpClient(g *protogen.GeneratedFile, service *protogen.Service) {
+	clientName := service.GoName + "Client"
+	typeName := unexport(service.GoName) + "HttpClient"
+	httpClientType := alchemyPackage.Ident("HttpClient")
+
+	g.P("// New", service.GoName, "HttpClient creates a ", clientName, " which invokes the methods")
+	g.P("// of the ", service.GoName, " over HTTP by their routes.")
+	g.P("//")
+	g.P("// The grpc.CallOptions passed to the methods are ignored, e.g. grpc.Header")
+	g.P("// and grpc.Trailer are not filled in with the response headers.")
+	g.P("func New", service.GoName, "HttpClient(cc *", httpClientType, ") ", clientName, " {")
+	g.P("return &", typeName, "{cc}")
+	g.P("}")
+	g.P()
+
+	g.P("type ", typeName, " struct {")
+	g.P("cc *", httpClientType)
+	g.P("}")
+	g.P()
+
+	var routeIndex int
+	for _, method := range service.Methods {
+		var routes int
+		for range visitHttpRules(method.Desc.Options()) {
+			routes++
+		}
+
+		g.P("func (c *", typeName, ") ", httpClientMethodSignature(g, method), " {")
+		switch {
+		case method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer():
+			g.P("return nil, ", statusPackage.Ident("Error"), "(", codesPackage.Ident("Unimplemented"), ", ",
+				strconv.Quote("method "+method.GoName+" is a streaming method not supported over HTTP"), ")")
+		case routes == 0:
+			g.P("return nil, ", statusPackage.Ident("Error"), "(", codesPackage.Ident("Unimplemented"), ", ",
+				strconv.Quote("method "+method.GoName+" is not exposed over HTTP"), ")")
+		default:
+			g.P("out := new(", method.Output.GoIdent, ")")
+			g.P("if err := c.cc.Invoke(ctx, &", serviceDescVar(service), ".Routes[", routeIndex, "], in, out); err != nil {")
+			g.P("return nil, err")
+			g.P("}")
+			g.P("return out, nil")
+		}
+		g.P("}")
+		g.P()
+
+		routeIndex += routes
+	}
+}
+
+// httpClientMethodSignature returns the signature of the client method as
+// generated by protoc-gen-go-grpc with the generic streams.
+func httpClientMethodSignature(g *protogen.GeneratedFile, method *protogen.Method) string {
+	var sb strings.Builder
+	sb.WriteString(method.GoName + "(ctx " + g.QualifiedGoIdent(contextPackage.Ident("Context")))
+	if !method.Desc.IsStreamingClient() {
+		sb.WriteString(", in *" + g.QualifiedGoIdent(method.Input.GoIdent))
+	}
+	sb.WriteString(", opts ..." + g.QualifiedGoIdent(grpcPackage.Ident("CallOption")) + ") (")
+
+	input, output := g.QualifiedGoIdent(method.Input.GoIdent), g.QualifiedGoIdent(method.Output.GoIdent)
+	switch {
+	case method.Desc.IsStreamingClient() && method.Desc.IsStreamingServer():
+		sb.WriteString(g.QualifiedGoIdent(grpcPackage.Ident("BidiStreamingClient")) + "[" + input + ", " + output + "]")
+	case method.Desc.IsStreamingClient():
+		sb.WriteString(g.QualifiedGoIdent(grpcPackage.Ident("ClientStreamingClient")) + "[" + input + ", " + output + "]")
+	case method.Desc.IsStreamingServer():
+		sb.WriteString(g.QualifiedGoIdent(grpcPackage.Ident("ServerStreamingClient")) + "[" + output + "]")
+	default:
+		sb.WriteString("*" + output)
+	}
+	sb.WriteString(", error)")
+
+	return sb.String()
+}
+
+// unexport lowercases the first letter of the name.
+func unexport(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
@@ -23,8 +23,16 @@ var (
 // SupportedFeatures reports the set of supported protobuf language features
 var SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
 
+// Options configures the generated code.
+type Options struct {
+	OpenAPI    bool // whether to generate and embed the OpenAPI documents of the services
+	HttpClient bool // whether to generate the HTTP clients of the services
+	TypeScript bool // whether to generate the TypeScript types and clients of the services
+	Mock       bool // whether to generate the stubs and the mocks of the services
+}
+
 // GenerateFile generates the contents of a .alchemy.go file
-func GenerateFile(gen *protogen.Plugin, file *protogen.File) error {
+func GenerateFile(gen *protogen.Plugin, file *protogen.File, opts Options) error {
 	if len(file.Services) == 0 {
 		return nil
 	}
@@ -35,10 +43,30 @@ func GenerateFile(gen *protogen.Plugin, file *protogen.File) error {
 	genVersionHeader(g, gen)
 	genGoPackageHeader(g, file)
 	for _, service := range file.Services {
+		var openapi string
+		if opts.OpenAPI {
+			filename, err := genOpenAPI(gen, file, service)
+			if err != nil {
+				return err
+			}
+			openapi = genOpenAPIEmbed(g, service, filename)
+		}
+
 		genServiceRegister(g, service)
-		if err := genServiceDesc(g, service); err != nil {
+		if err := genServiceDesc(g, service, openapi); err != nil {
 			return err
 		}
+		if opts.HttpClient {
+			genHttpClient(g, service)
+		}
+	}
+	if opts.TypeScript {
+		if err := genTypeScript(gen, file); err != nil {
+			return err
+		}
+	}
+	if opts.Mock {
+		genMock(gen, file)
 	}
 
 	return nil
@@ -77,17 +105,34 @@ func genServiceRegister(g *protogen.GeneratedFile, service *protogen.Service) {
 	g.P("}")
 }
 
-func genServiceDesc(g *protogen.GeneratedFile, service *protogen.Service) error {
+func genOpenAPIEmbed(g *protogen.GeneratedFile, service *protogen.Service, filename string) string {
+	g.Import("embed")
+
+	varName := "_" + service.GoName + "_OpenAPI"
+	g.P("// ", varName, " is the OpenAPI document of the ", service.GoName, " service.")
+	g.P("//")
+	g.P("//go:embed ", filename)
+	g.P("var ", varName, " []byte")
+	g.P()
+
+	return varName
+}
+
+func genServiceDesc(g *protogen.GeneratedFile, service *protogen.Service, openapi string) error {
 	g.P("// "+serviceDescVar(service), " defines the Alchemy service descriptor for the ", service.GoName, " service.")
 	g.P("var ", serviceDescVar(service), " = ", alchemyPackage.Ident("ServiceDesc"), "{")
 	{
 		g.P("GrpcServiceDesc: &", service.GoName+"_ServiceDesc,")
+		if len(openapi) != 0 {
+			g.P("OpenAPI: ", openapi, ",")
+		}
 		g.P("Routes: []", alchemyPackage.Ident("RouteDesc"), "{")
 		for _, method := range service.Methods {
 			for rule := range visitHttpRules(method.Desc.Options()) {
 				httpMethod, pathPattern := parseMethodWithPattern(rule)
 				g.P("{")
 				{
+					g.P("FullMethod: ", strconv.Quote(fmt.Sprintf("/%s/%s", service.Desc.FullName(), method.Desc.Name())), ",")
 					g.P("HttpMethod: ", strconv.Quote(httpMethod), ",")
 					g.P("PathPattern: ", strconv.Quote(pathPattern), ",")
 					g.P("Handler: _", service.GoName, "_", method.GoName, "_Handler,")
@@ -122,6 +167,11 @@ func genServiceDesc(g *protogen.GeneratedFile, service *protogen.Service) error
 					if err != nil {
 						return err
 					}
+					for _, pathParameterName := range pathParameterNames {
+						if _, err = resolveGoFieldPath(method.Input, pathParameterName); err != nil {
+							return err
+						}
+					}
 					if len(pathParameterNames) != 0 {
 						g.P("PathParameters: ", fmt.Sprintf("%#v", pathParameterNames), ",")
 					}
@@ -142,7 +192,9 @@ func serviceDescVar(service *protogen.Service) string {
 
 func visitHttpRules(options proto.Message) iter.Seq[*annotations.HttpRule] {
 	queue := make([]*annotations.HttpRule, 0, 32)
-	queue = append(queue, proto.GetExtension(options, annotations.E_Http).(*annotations.HttpRule))
+	if rule := proto.GetExtension(options, annotations.E_Http).(*annotations.HttpRule); rule != nil {
+		queue = append(queue, rule)
+	}
 	return func(yield func(*annotations.HttpRule) bool) {
 		for len(queue) != 0 {
 			curr := queue[0]
@@ -0,0 +1,376 @@
+package gengo_test
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/cmd/protoc-gen-go/internal_gengo"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+
+	"github.com/wjiec/alchemy/cmd/protoc-gen-alchemy/internal/gengo"
+)
+
+const libraryProto = `
+name: "library/v1/library.proto"
+package: "library.v1"
+dependency: ["google/api/annotations.proto", "buf/validate/validate.proto"]
+options { go_package: "example.com/library/v1;libraryv1" }
+message_type {
+  name: "Book"
+  field { name: "name" number: 1 type: TYPE_STRING label: LABEL_OPTIONAL json_name: "name" }
+  field {
+    name: "page_count" number: 2 type: TYPE_INT32 label: LABEL_OPTIONAL json_name: "pageCount"
+    options { [buf.validate.field] { int32 { gte: 1 } } }
+  }
+  field {
+    name: "title" number: 3 type: TYPE_STRING label: LABEL_OPTIONAL json_name: "title"
+    options { [buf.validate.field] { required: true string { min_len: 1 max_len: 64 } } }
+  }
+}
+message_type {
+  name: "GetBookRequest"
+  field { name: "name" number: 1 type: TYPE_STRING label: LABEL_OPTIONAL json_name: "name" }
+  field { name: "view" number: 2 type: TYPE_STRING label: LABEL_OPTIONAL json_name: "view" }
+}
+message_type {
+  name: "CreateBookRequest"
+  field { name: "book" number: 1 type: TYPE_MESSAGE type_name: ".library.v1.Book" label: LABEL_OPTIONAL json_name: "book" }
+}
+service {
+  name: "LibraryService"
+  method {
+    name: "GetBook" input_type: ".library.v1.GetBookRequest" output_type: ".library.v1.Book"
+    options { [google.api.http] { get: "/v1/{name=books/*}" } }
+  }
+  method {
+    name: "CreateBook" input_type: ".library.v1.CreateBookRequest" output_type: ".library.v1.Book"
+    options { [google.api.http] { post: "/v1/books" body: "book" } }
+  }
+  method { name: "WatchBook" input_type: ".library.v1.GetBookRequest" output_type: ".library.v1.Book" server_streaming: true }
+  method { name: "Ping" input_type: ".library.v1.GetBookRequest" output_type: ".library.v1.Book" }
+}
+syntax: "proto3"
+`
+
+// Generate runs the generator over the library.proto and returns the generated files.
+func Generate(t *testing.T, opts gengo.Options) map[string]string {
+	return GenerateProto(t, opts, libraryProto)
+}
+
+// GenerateProto runs the generator over the last of the files in the text format,
+// the other files are its dependencies, and returns the generated files.
+func GenerateProto(t *testing.T, opts gengo.Options, protos ...string) map[string]string {
+	return GenerateWith(t, func(gen *protogen.Plugin) error {
+		for _, f := range gen.Files {
+			if f.Generate {
+				if err := gengo.GenerateFile(gen, f, opts); err != nil {
+					return err
+				}
+			}
+		}
+		if opts.TypeScript {
+			gengo.GenerateTypeScriptRuntime(gen)
+		}
+		return nil
+	}, protos...)
+}
+
+// GenerateWith runs the generate function over the last of the files in the text
+// format, the other files are its dependencies, and returns the generated files.
+func GenerateWith(t *testing.T, generate func(gen *protogen.Plugin) error, protos ...string) map[string]string {
+	descriptors := make([]*descriptorpb.FileDescriptorProto, len(protos))
+	for i, proto := range protos {
+		descriptors[i] = new(descriptorpb.FileDescriptorProto)
+		require.NoError(t, prototext.Unmarshal([]byte(proto), descriptors[i]))
+	}
+	file := descriptors[len(descriptors)-1]
+
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file.GetName()},
+		Parameter:      ptr("paths=source_relative"),
+	}
+
+	seen := make(map[string]bool)
+	var addFile func(fd protoreflect.FileDescriptor)
+	addFile = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+
+		for i := 0; i < fd.Imports().Len(); i++ {
+			addFile(fd.Imports().Get(i).FileDescriptor)
+		}
+		req.ProtoFile = append(req.ProtoFile, protodesc.ToFileDescriptorProto(fd))
+	}
+	for _, f := range descriptors {
+		for _, dependency := range f.GetDependency() {
+			if !seen[dependency] {
+				fd, err := protoregistry.GlobalFiles.FindFileByPath(dependency)
+				require.NoError(t, err)
+				addFile(fd)
+			}
+		}
+		seen[f.GetName()] = true
+		req.ProtoFile = append(req.ProtoFile, f)
+	}
+
+	gen, err := protogen.Options{}.New(req)
+	require.NoError(t, err)
+	require.NoError(t, generate(gen))
+
+	resp := gen.Response()
+	require.Empty(t, resp.GetError())
+
+	files := make(map[string]string)
+	for _, f := range resp.GetFile() {
+		files[f.GetName()] = f.GetContent()
+	}
+	return files
+}
+
+func ptr(s string) *string { return &s }
+
+func TestGenerateFile(t *testing.T) {
+	files := Generate(t, gengo.Options{})
+	if assert.Contains(t, files, "library/v1/library.pb.alchemy.go") {
+		content := files["library/v1/library.pb.alchemy.go"]
+		assert.Contains(t, content, `FullMethod:     "/library.v1.LibraryService/GetBook",`)
+		assert.Contains(t, content, `PathPattern:    "/v1/{name=books/*}",`)
+		assert.NotContains(t, content, "OpenAPI")
+		assert.NotContains(t, content, "HttpClient")
+	}
+	assert.Len(t, files, 1)
+}
+
+func TestGenerateFile_Compiled(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping compiling the generated code in short mode")
+	}
+
+	files := GenerateWith(t, func(gen *protogen.Plugin) error {
+		for _, f := range gen.Files {
+			if f.Generate {
+				internal_gengo.GenerateFile(gen, f)
+				if err := gengo.GenerateFile(gen, f, gengo.Options{HttpClient: true, Mock: true}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}, libraryProto)
+
+	// The fixture module is made of the generated files, the gRPC stubs and
+	// the test of the mock called through the HTTP client in the testdata.
+	root, err := filepath.Abs("../../../..")
+	require.NoError(t, err)
+	dir := t.TempDir()
+	require.NoError(t, os.CopyFS(dir, os.DirFS("testdata")))
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	goSum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.sum"), goSum, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com\n\n"+
+		"go 1.24.3\n\n"+
+		"require github.com/wjiec/alchemy v0.0.0\n\n"+
+		"replace github.com/wjiec/alchemy => "+root+"\n"), 0o644))
+
+	cmd := exec.Command("go", "test", "-count=1", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func TestGenerateFile_HttpClient(t *testing.T) {
+	files := Generate(t, gengo.Options{HttpClient: true})
+	if assert.Contains(t, files, "library/v1/library.pb.alchemy.go") {
+		content := files["library/v1/library.pb.alchemy.go"]
+		assert.Contains(t, content, "// The grpc.CallOptions passed to the methods are ignored")
+		assert.Contains(t, content, "func NewLibraryServiceHttpClient(cc *alchemy.HttpClient) LibraryServiceClient {")
+		assert.Contains(t, content, "func (c *libraryServiceHttpClient) GetBook(ctx context.Context, in *GetBook"+
+			"Request, opts ...grpc.CallOption) (*Book, error) {\n\tout := new(Book)\n"+
+			"\tif err := c.cc.Invoke(ctx, &_LibraryService_AlchemyServiceDesc.Routes[0], in, out); err != nil {")
+		assert.Contains(t, content, "c.cc.Invoke(ctx, &_LibraryService_AlchemyServiceDesc.Routes[1], in, out)")
+		assert.Contains(t, content, "func (c *libraryServiceHttpClient) WatchBook(ctx context.Context, in *GetBookRequest, "+
+			"opts ...grpc.CallOption) (grpc.ServerStreamingClient[Book], error) {")
+		assert.Contains(t, content, `status.Error(codes.Unimplemented, "method WatchBook is a streaming method not supported over HTTP")`)
+		assert.Contains(t, content, `status.Error(codes.Unimplemented, "method Ping is not exposed over HTTP")`)
+	}
+}
+
+func TestGenerateFile_Mock(t *testing.T) {
+	files := Generate(t, gengo.Options{Mock: true})
+	if assert.Contains(t, files, "library/v1/library.pb.alchemy.mock.go") {
+		content := files["library/v1/library.pb.alchemy.mock.go"]
+		assert.Contains(t, content, "type LibraryServiceStub struct {\n\tUnimplementedLibraryServiceServer\n}")
+		assert.Contains(t, content, "func (LibraryServiceStub) GetBook(ctx context.Context, in *GetBookRequest) (*Book, error) {\n"+
+			"\treturn nil, bizerr.New(uint32(codes.Unimplemented), http.StatusNotImplemented, \"method GetBook not implemented\")")
+		assert.Contains(t, content, "func (LibraryServiceStub) WatchBook(in *GetBookRequest, stream grpc.ServerStreamingServer[Book]) error {\n"+
+			"\treturn bizerr.New(uint32(codes.Unimplemented), http.StatusNotImplemented, \"method WatchBook not implemented\")")
+
+		assert.Contains(t, content, "type LibraryServiceMock struct {\n\tLibraryServiceStub\n")
+		assert.Contains(t, content, "func (m *LibraryServiceMock) OnGetBook(fn func(ctx context.Context, in *GetBookRequest) (*Book, error)) *LibraryServiceMock {")
+		assert.Contains(t, content, "func (m *LibraryServiceMock) GetBookCalls() []*GetBookRequest {")
+		assert.Contains(t, content, "if fn == nil {\n\t\treturn m.LibraryServiceStub.GetBook(ctx, in)\n\t}")
+		assert.NotContains(t, content, "OnWatchBook")
+	}
+}
+
+func TestGenerateFile_OpenAPI(t *testing.T) {
+	files := Generate(t, gengo.Options{OpenAPI: true})
+	if assert.Contains(t, files, "library/v1/library.pb.alchemy.go") {
+		content := files["library/v1/library.pb.alchemy.go"]
+		assert.Contains(t, content, "//go:embed library.LibraryService.openapi.json\nvar _LibraryService_OpenAPI []byte")
+		assert.Contains(t, content, "OpenAPI:         _LibraryService_OpenAPI,")
+	}
+
+	require.Contains(t, files, "library/v1/library.LibraryService.openapi.json")
+
+	var doc struct {
+		OpenAPI string
+		Info    struct{ Title, Version string }
+		Paths   map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+			Parameters  []struct {
+				Name, In string
+				Required bool
+			}
+			RequestBody *struct {
+				Content map[string]struct {
+					Schema map[string]any
+				}
+			} `json:"requestBody"`
+		}
+		Components struct {
+			Schemas map[string]json.RawMessage
+		}
+	}
+	require.NoError(t, json.Unmarshal([]byte(files["library/v1/library.LibraryService.openapi.json"]), &doc))
+
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.Equal(t, "library.v1.LibraryService", doc.Info.Title)
+	assert.Equal(t, "v1", doc.Info.Version)
+
+	if getBook, found := doc.Paths["/v1/{name}"]["get"]; assert.True(t, found) {
+		assert.Equal(t, "LibraryService_GetBook", getBook.OperationID)
+		if assert.Len(t, getBook.Parameters, 2) {
+			assert.Equal(t, "name", getBook.Parameters[0].Name)
+			assert.Equal(t, "path", getBook.Parameters[0].In)
+			assert.True(t, getBook.Parameters[0].Required)
+			assert.Equal(t, "view", getBook.Parameters[1].Name)
+			assert.Equal(t, "query", getBook.Parameters[1].In)
+		}
+		assert.Nil(t, getBook.RequestBody)
+	}
+
+	if createBook, found := doc.Paths["/v1/books"]["post"]; assert.True(t, found) {
+		assert.Empty(t, createBook.Parameters)
+		if assert.NotNil(t, createBook.RequestBody) {
+			assert.Equal(t, map[string]any{"$ref": "#/components/schemas/library.v1.Book"},
+				createBook.RequestBody.Content["application/json"].Schema)
+		}
+	}
+
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"},
+			"pageCount": {"type": "integer", "format": "int32", "minimum": 1},
+			"title": {"type": "string", "minLength": 1, "maxLength": 64}
+		},
+		"required": ["title"]
+	}`, string(doc.Components.Schemas["library.v1.Book"]))
+	assert.Contains(t, doc.Components.Schemas, "google.rpc.Status")
+}
+
+func TestGenerateFile_TypeScript(t *testing.T) {
+	files := Generate(t, gengo.Options{TypeScript: true})
+	require.Contains(t, files, "library/v1/library.alchemy.ts")
+	require.Contains(t, files, gengo.TypeScriptRuntimeFile)
+	assert.Len(t, files, 3)
+
+	runtime := files[gengo.TypeScriptRuntimeFile]
+	assert.Contains(t, runtime, "export class AlchemyError extends Error {")
+	assert.Contains(t, runtime, "export async function send<T>(")
+
+	content := files["library/v1/library.alchemy.ts"]
+	assert.NotContains(t, content, "class AlchemyError")
+	assert.Contains(t, content, "import * as alchemy from \"../../alchemy_runtime\";\n")
+	assert.Contains(t, content, "export { AlchemyError } from \"../../alchemy_runtime\";\n")
+	assert.Contains(t, content, "  constructor(private readonly options: alchemy.AlchemyClientOptions = {}) {}\n")
+	assert.Contains(t, content, "export interface Book {\n  name?: string;\n  pageCount?: number;\n  title?: string;\n}")
+	assert.Contains(t, content, "export interface GetBookRequest {\n  name?: string;\n  view?: string;\n}")
+	assert.Contains(t, content, "export class LibraryServiceClient {")
+	assert.Contains(t, content, "  getBook(request: GetBookRequest, init?: RequestInit): Promise<Book> {\n"+
+		"    return alchemy.send(this.options, \"GET\", `/v1/${alchemy.pathParam(request.name, \"name\", true)}"+
+		"${alchemy.query(request, [\"view\"])}`, undefined, false, false, init);\n  }")
+	assert.Contains(t, content, "  createBook(request: CreateBookRequest, init?: RequestInit): Promise<Book> {\n"+
+		"    return alchemy.send(this.options, \"POST\", `/v1/books`, request.book, false, false, init);\n  }")
+	assert.NotContains(t, content, "watchBook")
+	assert.NotContains(t, content, "ping")
+}
+
+const multipartProto = `
+name: "multipart/multipart.proto"
+package: "alchemy.multipart"
+options { go_package: "github.com/wjiec/alchemy/multipart" }
+message_type {
+  name: "Multipart"
+  field { name: "ids" number: 2 type: TYPE_STRING label: LABEL_REPEATED json_name: "ids" }
+}
+syntax: "proto3"
+`
+
+const uploadProto = `
+name: "upload/v1/upload.proto"
+package: "upload.v1"
+dependency: ["google/api/annotations.proto", "multipart/multipart.proto"]
+options { go_package: "example.com/upload/v1;uploadv1" }
+message_type {
+  name: "UploadAvatarRequest"
+  field { name: "user" number: 1 type: TYPE_STRING label: LABEL_OPTIONAL json_name: "user" }
+  field { name: "avatar" number: 2 type: TYPE_MESSAGE type_name: ".alchemy.multipart.Multipart" label: LABEL_OPTIONAL json_name: "avatar" }
+  field { name: "caption" number: 3 type: TYPE_STRING label: LABEL_OPTIONAL json_name: "caption" }
+}
+message_type {
+  name: "Avatar"
+  field { name: "url" number: 1 type: TYPE_STRING label: LABEL_OPTIONAL json_name: "url" }
+}
+service {
+  name: "UploadService"
+  method {
+    name: "UploadAvatar" input_type: ".upload.v1.UploadAvatarRequest" output_type: ".upload.v1.Avatar"
+    options { [google.api.http] { post: "/v1/{user=users/*}/avatar" body: "*" } }
+  }
+}
+syntax: "proto3"
+`
+
+func TestGenerateFile_TypeScriptMultipart(t *testing.T) {
+	files := GenerateProto(t, gengo.Options{TypeScript: true}, multipartProto, uploadProto)
+	require.Contains(t, files, "upload/v1/upload.alchemy.ts")
+
+	content := files["upload/v1/upload.alchemy.ts"]
+	assert.Contains(t, content, "export interface UploadAvatarRequest {\n  user?: string;\n  avatar?: Blob[];\n  caption?: string;\n}")
+	assert.Contains(t, content, "  uploadAvatar(request: UploadAvatarRequest, init?: RequestInit): Promise<Avatar> {\n"+
+		"    return alchemy.send(this.options, \"POST\", `/v1/${alchemy.pathParam(request.user, \"user\", true)}/avatar`, "+
+		"request, true, false, init);\n  }")
+	assert.Contains(t, files[gengo.TypeScriptRuntimeFile], "payload = formData(body);")
+}
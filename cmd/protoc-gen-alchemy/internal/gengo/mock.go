@@ -0,0 +1,140 @@
+package gengo
+
+import (
+	"strconv"
+
+	"google.golang.org/protobuf/compiler/protogen"
+)
+
+var (
+	bizerrPackage = protogen.GoImportPath("github.com/wjiec/alchemy/bizerr")
+	httpPackage   = protogen.GoImportPath("net/http")
+	syncPackage   = protogen.GoImportPath("sync")
+	slicesPackage = protogen.GoImportPath("slices")
+)
+
+// genMock generates the stubs and the mocks of the services of the file.
+func genMock(gen *protogen.Plugin, file *protogen.File) {
+	g := gen.NewGeneratedFile(file.GeneratedFilenamePrefix+".pb.alchemy.mock.go", file.GoImportPath)
+	genGeneratedHeader(g, file)
+	genVersionHeader(g, gen)
+	genGoPackageHeader(g, file)
+	for _, service := range file.Services {
+		genStub(g, service)
+		genServiceMock(g, service)
+	}
+}
+
+// genStub generates the stub of the service, whose methods respond with the
+// Unimplemented business error.
+func genStub(g *protogen.GeneratedFile, service *protogen.Service) {
+	stubName := service.GoName + "Stub"
+
+	g.P("// ", stubName, " is a ", service.GoName, "Server whose methods respond with the")
+	g.P("// Unimplemented business error, it can be embedded to implement a part of the methods.")
+	g.P("type ", stubName, " struct {")
+	g.P("Unimplemented", service.GoName, "Server")
+	g.P("}")
+	g.P()
+
+	for _, method := range service.Methods {
+		g.P("func (", stubName, ") ", serverMethodSignature(g, method), " {")
+		errorExpr := g.QualifiedGoIdent(bizerrPackage.Ident("New")) + "(uint32(" + g.QualifiedGoIdent(codesPackage.Ident("Unimplemented")) +
+			"), " + g.QualifiedGoIdent(httpPackage.Ident("StatusNotImplemented")) + ", " + strconv.Quote("method "+method.GoName+" not implemented") + ")"
+		if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+			g.P("return ", errorExpr)
+		} else {
+			g.P("return nil, ", errorExpr)
+		}
+		g.P("}")
+		g.P()
+	}
+}
+
+// genServiceMock generates the mock of the service, which records the requests
+// of the unary methods and responds with the programmed functions.
+func genServiceMock(g *protogen.GeneratedFile, service *protogen.Service) {
+	mockName := service.GoName + "Mock"
+	stubName := service.GoName + "Stub"
+
+	var methods []*protogen.Method
+	for _, method := range service.Methods {
+		if !method.Desc.IsStreamingClient() && !method.Desc.IsStreamingServer() {
+			methods = append(methods, method)
+		}
+	}
+
+	g.P("// ", mockName, " is a ", service.GoName, "Server which records the requests of the unary")
+	g.P("// methods and responds with the functions programmed by the On methods, the methods")
+	g.P("// without a function and the streaming methods respond as the ", stubName, ".")
+	g.P("//")
+	g.P("// It is safe to program and inspect the mock while it is serving.")
+	g.P("type ", mockName, " struct {")
+	g.P(stubName)
+	g.P()
+	g.P("mu ", syncPackage.Ident("Mutex"))
+	for _, method := range methods {
+		g.P(unexport(method.GoName), "Func ", mockFuncType(g, method))
+		g.P(unexport(method.GoName), "Calls []*", method.Input.GoIdent)
+	}
+	g.P("}")
+	g.P()
+
+	for _, method := range methods {
+		funcField, callsField := unexport(method.GoName)+"Func", unexport(method.GoName)+"Calls"
+
+		g.P("// On", method.GoName, " programs the response of ", method.GoName, ".")
+		g.P("func (m *", mockName, ") On", method.GoName, "(fn ", mockFuncType(g, method), ") *", mockName, " {")
+		g.P("m.mu.Lock()")
+		g.P("defer m.mu.Unlock()")
+		g.P()
+		g.P("m.", funcField, " = fn")
+		g.P("return m")
+		g.P("}")
+		g.P()
+
+		g.P("// ", method.GoName, "Calls returns the requests of the calls of ", method.GoName, " in order.")
+		g.P("func (m *", mockName, ") ", method.GoName, "Calls() []*", method.Input.GoIdent, " {")
+		g.P("m.mu.Lock()")
+		g.P("defer m.mu.Unlock()")
+		g.P()
+		g.P("return ", slicesPackage.Ident("Clone"), "(m.", callsField, ")")
+		g.P("}")
+		g.P()
+
+		g.P("func (m *", mockName, ") ", serverMethodSignature(g, method), " {")
+		g.P("m.mu.Lock()")
+		g.P("m.", callsField, " = append(m.", callsField, ", in)")
+		g.P("fn := m.", funcField)
+		g.P("m.mu.Unlock()")
+		g.P()
+		g.P("if fn == nil {")
+		g.P("return m.", stubName, ".", method.GoName, "(ctx, in)")
+		g.P("}")
+		g.P("return fn(ctx, in)")
+		g.P("}")
+		g.P()
+	}
+}
+
+// mockFuncType returns the type of the function programming the response of a unary method.
+func mockFuncType(g *protogen.GeneratedFile, method *protogen.Method) string {
+	return "func(ctx " + g.QualifiedGoIdent(contextPackage.Ident("Context")) + ", in *" + g.QualifiedGoIdent(method.Input.GoIdent) +
+		") (*" + g.QualifiedGoIdent(method.Output.GoIdent) + ", error)"
+}
+
+// serverMethodSignature returns the signature of the server method as generated
+// by protoc-gen-go-grpc with the generic streams.
+func serverMethodSignature(g *protogen.GeneratedFile, method *protogen.Method) string {
+	input, output := g.QualifiedGoIdent(method.Input.GoIdent), g.QualifiedGoIdent(method.Output.GoIdent)
+	switch {
+	case method.Desc.IsStreamingClient() && method.Desc.IsStreamingServer():
+		return method.GoName + "(stream " + g.QualifiedGoIdent(grpcPackage.Ident("BidiStreamingServer")) + "[" + input + ", " + output + "]) error"
+	case method.Desc.IsStreamingClient():
+		return method.GoName + "(stream " + g.QualifiedGoIdent(grpcPackage.Ident("ClientStreamingServer")) + "[" + input + ", " + output + "]) error"
+	case method.Desc.IsStreamingServer():
+		return method.GoName + "(in *" + input + ", stream " + g.QualifiedGoIdent(grpcPackage.Ident("ServerStreamingServer")) + "[" + output + "]) error"
+	default:
+		return method.GoName + "(ctx " + g.QualifiedGoIdent(contextPackage.Ident("Context")) + ", in *" + input + ") (*" + output + ", error)"
+	}
+}
@@ -0,0 +1,764 @@
+package gengo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/wjiec/alchemy/cmd/protoc-gen-alchemy/internal/gengo/pattern"
+)
+
+// genOpenAPI generates the OpenAPI 3.1 document of the service, and returns
+// the name of the generated file relative to the generated Go file.
+func genOpenAPI(gen *protogen.Plugin, file *protogen.File, service *protogen.Service) (string, error) {
+	b := &openapiBuilder{
+		doc: &openapiDocument{
+			OpenAPI: "3.1.0",
+			Info: openapiInfo{
+				Title:       string(service.Desc.FullName()),
+				Description: commentText(service.Comments.Leading),
+				Version:     packageVersion(file.Desc.Package()),
+			},
+			Tags:  []openapiTag{{Name: string(service.Desc.FullName()), Description: commentText(service.Comments.Leading)}},
+			Paths: make(map[string]openapiPathItem),
+			Components: openapiComponents{
+				Schemas: map[string]*openapiSchema{statusSchemaName: statusSchema()},
+			},
+		},
+		seen: make(map[protoreflect.FullName]bool),
+	}
+
+	for _, method := range service.Methods {
+		index := 0
+		for rule := range visitHttpRules(method.Desc.Options()) {
+			if err := b.addOperation(service, method, rule, index); err != nil {
+				return "", err
+			}
+			index++
+		}
+	}
+
+	data, err := json.MarshalIndent(b.doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	filename := file.GeneratedFilenamePrefix + "." + service.GoName + ".openapi.json"
+	g := gen.NewGeneratedFile(filename, "")
+	_, _ = g.Write(append(data, '\n'))
+
+	return path.Base(filename), nil
+}
+
+// statusSchemaName is the name of the schema of the error responses.
+const statusSchemaName = "google.rpc.Status"
+
+// statusSchema returns the schema of the google.rpc.Status message, which
+// is the body of the error responses.
+func statusSchema() *openapiSchema {
+	return &openapiSchema{
+		Type:        "object",
+		Description: "The error response of the request.",
+		Properties: openapiProperties{
+			{Name: "code", Schema: &openapiSchema{Type: "integer", Format: "int32", Description: "The status code, which should be an enum value of google.rpc.Code."}},
+			{Name: "message", Schema: &openapiSchema{Type: "string", Description: "A developer-facing error message."}},
+			{Name: "details", Schema: &openapiSchema{
+				Type:        "array",
+				Description: "A list of messages that carry the error details.",
+				Items: &openapiSchema{
+					Type:       "object",
+					Properties: openapiProperties{{Name: "@type", Schema: &openapiSchema{Type: "string"}}},
+				},
+			}},
+		},
+	}
+}
+
+// openapiBuilder builds the OpenAPI document of a service.
+type openapiBuilder struct {
+	doc  *openapiDocument
+	seen map[protoreflect.FullName]bool
+}
+
+// addOperation adds the operation of the method bound by the HttpRule to the document.
+func (b *openapiBuilder) addOperation(service *protogen.Service, method *protogen.Method, rule *annotations.HttpRule, index int) error {
+	httpMethod, pathPattern := parseMethodWithPattern(rule)
+	openapiPath, pathParameters, err := pattern.OpenAPIPath(pathPattern)
+	if err != nil {
+		return err
+	}
+
+	operation := &openapiOperation{
+		Tags:        []string{string(service.Desc.FullName())},
+		Description: commentText(method.Comments.Leading),
+		OperationID: service.GoName + "_" + method.GoName,
+		Deprecated:  method.Desc.Options().(*descriptorpb.MethodOptions).GetDeprecated(),
+		Responses: map[string]*openapiResponse{
+			"200": b.response(method.Output, rule.ResponseBody),
+			"default": {
+				Description: "An unexpected error response.",
+				Content:     map[string]openapiMediaType{"application/json": {Schema: schemaRef(statusSchemaName)}},
+			},
+		},
+	}
+	if index != 0 {
+		operation.OperationID += fmt.Sprintf("_%d", index)
+	}
+
+	excluded := make(map[string]bool)
+	for _, parameter := range pathParameters {
+		field, err := resolveField(method.Input, parameter.Name)
+		if err != nil {
+			return err
+		}
+
+		schema := parameterSchema(b.fieldSchema(field))
+		if len(parameter.Regexp) != 0 {
+			schema.Pattern = "^(?:" + parameter.Regexp + ")$"
+		}
+
+		description := commentText(field.Comments.Leading)
+		if parameter.Template != "*" {
+			description = strings.TrimSpace(description + "\n\nMatches the pattern `" + parameter.Template + "`.")
+		}
+		operation.Parameters = append(operation.Parameters, &openapiParameter{
+			Name:        parameter.Name,
+			In:          "path",
+			Description: description,
+			Required:    true,
+			Schema:      schema,
+		})
+		excluded[parameter.Name] = true
+	}
+
+	switch rule.Body {
+	case "*":
+		operation.RequestBody = b.requestBody(method.Input, nil)
+	case "":
+		operation.Parameters = append(operation.Parameters, b.queryParameters(method.Input, excluded)...)
+	default:
+		field, err := resolveField(method.Input, rule.Body)
+		if err != nil {
+			return err
+		}
+
+		operation.RequestBody = b.requestBody(field.Message, field)
+		excluded[rule.Body] = true
+		operation.Parameters = append(operation.Parameters, b.queryParameters(method.Input, excluded)...)
+	}
+
+	item, found := b.doc.Paths[openapiPath]
+	if !found {
+		item = make(openapiPathItem)
+		b.doc.Paths[openapiPath] = item
+	}
+	item[strings.ToLower(httpMethod)] = operation
+
+	return nil
+}
+
+// requestBody returns the request body of the message, or of the field if it is not nil.
+func (b *openapiBuilder) requestBody(msg *protogen.Message, field *protogen.Field) *openapiRequestBody {
+	if msg != nil && isBinaryMessage(msg.Desc.FullName()) {
+		return &openapiRequestBody{
+			Required: true,
+			Content:  map[string]openapiMediaType{"application/octet-stream": {Schema: binarySchema()}},
+		}
+	}
+
+	var schema *openapiSchema
+	if field != nil {
+		schema = b.fieldSchema(field)
+	} else {
+		schema = b.messageSchema(msg)
+	}
+
+	body := &openapiRequestBody{Required: true, Content: map[string]openapiMediaType{"application/json": {Schema: schema}}}
+	if msg != nil && hasMultipartField(msg) {
+		body.Content["multipart/form-data"] = openapiMediaType{Schema: schema}
+	}
+	return body
+}
+
+// response returns the successful response of the message, or of the named field if
+// the name is neither empty nor "*".
+func (b *openapiBuilder) response(msg *protogen.Message, name string) *openapiResponse {
+	if len(name) != 0 && name != "*" {
+		if field, err := resolveField(msg, name); err == nil {
+			if field.Message != nil && isBinaryMessage(field.Message.Desc.FullName()) && !field.Desc.IsList() {
+				return binaryResponse()
+			}
+			return &openapiResponse{
+				Description: "A successful response.",
+				Content:     map[string]openapiMediaType{"application/json": {Schema: b.fieldSchema(field)}},
+			}
+		}
+	}
+
+	if isBinaryMessage(msg.Desc.FullName()) {
+		return binaryResponse()
+	}
+	return &openapiResponse{
+		Description: "A successful response.",
+		Content:     map[string]openapiMediaType{"application/json": {Schema: b.messageSchema(msg)}},
+	}
+}
+
+// binaryResponse returns a successful response with a raw body.
+func binaryResponse() *openapiResponse {
+	return &openapiResponse{
+		Description: "A successful response.",
+		Content:     map[string]openapiMediaType{"application/octet-stream": {Schema: binarySchema()}},
+	}
+}
+
+// queryParameters returns the query parameters of the fields of the message which
+// are not excluded, the fields of the nested messages are flattened with dotted names.
+func (b *openapiBuilder) queryParameters(msg *protogen.Message, excluded map[string]bool) []*openapiParameter {
+	var parameters []*openapiParameter
+	visitQueryFields(msg, "", "", excluded, nil, func(field *protogen.Field, jsonName string) {
+		parameters = append(parameters, &openapiParameter{
+			Name:        jsonName,
+			In:          "query",
+			Description: commentText(field.Comments.Leading),
+			Schema:      parameterSchema(b.fieldSchema(field)),
+		})
+	})
+	return parameters
+}
+
+// visitQueryFields calls fn with the fields of the message which can be bound to
+// the query parameters and are not excluded, the fields of the nested messages are
+// flattened with dotted names.
+func visitQueryFields(msg *protogen.Message, protoPrefix, jsonPrefix string, excluded map[string]bool, visited []protoreflect.FullName, fn func(field *protogen.Field, jsonName string)) {
+	if slices.Contains(visited, msg.Desc.FullName()) {
+		return
+	}
+	visited = append(visited, msg.Desc.FullName())
+
+	for _, field := range msg.Fields {
+		protoName, jsonName := protoPrefix+string(field.Desc.Name()), jsonPrefix+field.Desc.JSONName()
+		if excluded[protoName] || field.Desc.IsMap() {
+			continue
+		}
+
+		if field.Message != nil && !isScalarMessage(field.Message.Desc.FullName()) {
+			if !field.Desc.IsList() {
+				visitQueryFields(field.Message, protoName+".", jsonName+".", excluded, visited, fn)
+			}
+			continue
+		}
+		fn(field, jsonName)
+	}
+}
+
+// messageSchema returns the schema of the message, the messages which are not
+// well-known types are referenced from the components of the document.
+func (b *openapiBuilder) messageSchema(msg *protogen.Message) *openapiSchema {
+	if schema := wellKnownSchema(msg.Desc.FullName()); schema != nil {
+		return schema
+	}
+
+	name := msg.Desc.FullName()
+	if !b.seen[name] {
+		b.seen[name] = true
+
+		schema := &openapiSchema{Type: "object", Description: commentText(msg.Comments.Leading)}
+		for _, field := range msg.Fields {
+			fieldSchema := b.fieldSchema(field)
+			schema.Properties = append(schema.Properties, openapiProperty{Name: field.Desc.JSONName(), Schema: fieldSchema})
+			if isRequiredField(field) {
+				schema.Required = append(schema.Required, field.Desc.JSONName())
+			}
+		}
+		b.doc.Components.Schemas[string(name)] = schema
+	}
+
+	return schemaRef(string(name))
+}
+
+// enumSchema returns the reference to the schema of the enum.
+func (b *openapiBuilder) enumSchema(enum *protogen.Enum) *openapiSchema {
+	name := enum.Desc.FullName()
+	if !b.seen[name] {
+		b.seen[name] = true
+
+		schema := &openapiSchema{Type: "string", Description: commentText(enum.Comments.Leading)}
+		for _, value := range enum.Values {
+			schema.Enum = append(schema.Enum, string(value.Desc.Name()))
+		}
+		b.doc.Components.Schemas[string(name)] = schema
+	}
+
+	return schemaRef(string(name))
+}
+
+// fieldSchema returns the schema of the field, including its comments and constraints.
+func (b *openapiBuilder) fieldSchema(field *protogen.Field) *openapiSchema {
+	rules, _ := proto.GetExtension(field.Desc.Options(), validate.E_Field).(*validate.FieldRules)
+
+	var schema *openapiSchema
+	switch {
+	case field.Desc.IsMap():
+		value := field.Message.Fields[1]
+		schema = &openapiSchema{Type: "object", AdditionalProperties: b.valueSchema(value)}
+		applyMapRules(schema, rules.GetMap())
+		if valueRules := rules.GetMap().GetValues(); valueRules != nil {
+			applyRules(schema.AdditionalProperties, valueRules)
+		}
+	case field.Desc.IsList():
+		schema = &openapiSchema{Type: "array", Items: b.valueSchema(field)}
+		applyRepeatedRules(schema, rules.GetRepeated())
+		if itemRules := rules.GetRepeated().GetItems(); itemRules != nil {
+			applyRules(schema.Items, itemRules)
+		}
+	default:
+		schema = b.valueSchema(field)
+		applyRules(schema, rules)
+	}
+
+	schema.Description = commentText(field.Comments.Leading)
+	schema.Deprecated = field.Desc.Options().(*descriptorpb.FieldOptions).GetDeprecated()
+	for _, behavior := range fieldBehaviors(field) {
+		switch behavior {
+		case annotations.FieldBehavior_OUTPUT_ONLY:
+			schema.ReadOnly = true
+		case annotations.FieldBehavior_INPUT_ONLY:
+			schema.WriteOnly = true
+		}
+	}
+
+	// The siblings of $ref are only allowed since OpenAPI 3.1, but are ignored by
+	// some tools, so the referenced schemas are wrapped for the annotations.
+	if len(schema.Ref) != 0 && (len(schema.Description) != 0 || schema.Deprecated || schema.ReadOnly || schema.WriteOnly) {
+		schema.AllOf = []*openapiSchema{schemaRef(strings.TrimPrefix(schema.Ref, schemaRefPrefix))}
+		schema.Ref = ""
+	}
+	return schema
+}
+
+// parameterSchema returns the schema of a field bound to a parameter, the description
+// of the field is moved to the parameter.
+func parameterSchema(schema *openapiSchema) *openapiSchema {
+	if schema.Description = ""; len(schema.AllOf) == 1 && !schema.Deprecated && !schema.ReadOnly && !schema.WriteOnly {
+		return schema.AllOf[0]
+	}
+	return schema
+}
+
+// valueSchema returns the schema of a single value of the field.
+func (b *openapiBuilder) valueSchema(field *protogen.Field) *openapiSchema {
+	switch field.Desc.Kind() {
+	case protoreflect.BoolKind:
+		return &openapiSchema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &openapiSchema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &openapiSchema{Type: "integer", Format: "uint32"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return &openapiSchema{Type: "string", Format: "int64"}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return &openapiSchema{Type: "string", Format: "uint64"}
+	case protoreflect.FloatKind:
+		return &openapiSchema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &openapiSchema{Type: "number", Format: "double"}
+	case protoreflect.StringKind:
+		return &openapiSchema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &openapiSchema{Type: "string", Format: "byte"}
+	case protoreflect.EnumKind:
+		return b.enumSchema(field.Enum)
+	default:
+		return b.messageSchema(field.Message)
+	}
+}
+
+// schemaRefPrefix is the prefix of the references to the schemas of the components.
+const schemaRefPrefix = "#/components/schemas/"
+
+// schemaRef returns a reference to the named schema of the components.
+func schemaRef(name string) *openapiSchema {
+	return &openapiSchema{Ref: schemaRefPrefix + name}
+}
+
+// binarySchema returns the schema of a raw body.
+func binarySchema() *openapiSchema {
+	return &openapiSchema{Type: "string", Format: "binary"}
+}
+
+const (
+	multipartMessage = "alchemy.multipart.Multipart"
+	downloadMessage  = "alchemy.download.Download"
+	httpBodyMessage  = "google.api.HttpBody"
+)
+
+// isBinaryMessage reports whether the message is transferred as a raw body.
+func isBinaryMessage(name protoreflect.FullName) bool {
+	return name == httpBodyMessage || name == downloadMessage
+}
+
+// hasMultipartField reports whether the message has a Multipart field, so that
+// it can be sent as a multipart form.
+func hasMultipartField(msg *protogen.Message) bool {
+	return slices.ContainsFunc(msg.Fields, func(f *protogen.Field) bool {
+		return f.Message != nil && f.Message.Desc.FullName() == multipartMessage
+	})
+}
+
+// isScalarMessage reports whether the message is encoded as a JSON scalar,
+// so that it can be bound to a query parameter.
+func isScalarMessage(name protoreflect.FullName) bool {
+	schema := wellKnownSchema(name)
+	return schema != nil && schema.Type != "object" && schema.Type != "array" && len(schema.Type) != 0
+}
+
+// wellKnownSchema returns the schema of the well-known types and the messages
+// handled by alchemy, which have special JSON representations.
+func wellKnownSchema(name protoreflect.FullName) *openapiSchema {
+	switch name {
+	case "google.protobuf.Timestamp":
+		return &openapiSchema{Type: "string", Format: "date-time"}
+	case "google.protobuf.Duration":
+		return &openapiSchema{Type: "string", Pattern: `^-?[0-9]+(\.[0-9]+)?s$`}
+	case "google.protobuf.FieldMask":
+		return &openapiSchema{Type: "string"}
+	case "google.protobuf.Struct", "google.protobuf.Empty":
+		return &openapiSchema{Type: "object"}
+	case "google.protobuf.Value":
+		return &openapiSchema{}
+	case "google.protobuf.ListValue":
+		return &openapiSchema{Type: "array", Items: &openapiSchema{}}
+	case "google.protobuf.Any":
+		return &openapiSchema{
+			Type:       "object",
+			Properties: openapiProperties{{Name: "@type", Schema: &openapiSchema{Type: "string"}}},
+		}
+	case "google.protobuf.BoolValue":
+		return &openapiSchema{Type: "boolean"}
+	case "google.protobuf.Int32Value":
+		return &openapiSchema{Type: "integer", Format: "int32"}
+	case "google.protobuf.UInt32Value":
+		return &openapiSchema{Type: "integer", Format: "uint32"}
+	case "google.protobuf.Int64Value":
+		return &openapiSchema{Type: "string", Format: "int64"}
+	case "google.protobuf.UInt64Value":
+		return &openapiSchema{Type: "string", Format: "uint64"}
+	case "google.protobuf.FloatValue":
+		return &openapiSchema{Type: "number", Format: "float"}
+	case "google.protobuf.DoubleValue":
+		return &openapiSchema{Type: "number", Format: "double"}
+	case "google.protobuf.StringValue":
+		return &openapiSchema{Type: "string"}
+	case "google.protobuf.BytesValue":
+		return &openapiSchema{Type: "string", Format: "byte"}
+	case httpBodyMessage, downloadMessage:
+		return binarySchema()
+	case multipartMessage:
+		return &openapiSchema{Type: "array", Items: binarySchema()}
+	}
+	return nil
+}
+
+// isRequiredField reports whether the field is required by protovalidate or by
+// the google.api.field_behavior annotation.
+func isRequiredField(field *protogen.Field) bool {
+	rules, _ := proto.GetExtension(field.Desc.Options(), validate.E_Field).(*validate.FieldRules)
+	return rules.GetRequired() || slices.Contains(fieldBehaviors(field), annotations.FieldBehavior_REQUIRED)
+}
+
+// fieldBehaviors returns the google.api.field_behavior annotations of the field.
+func fieldBehaviors(field *protogen.Field) []annotations.FieldBehavior {
+	behaviors, _ := proto.GetExtension(field.Desc.Options(), annotations.E_FieldBehavior).([]annotations.FieldBehavior)
+	return behaviors
+}
+
+// applyRules applies the protovalidate rules of a single value to the schema.
+func applyRules(schema *openapiSchema, rules *validate.FieldRules) {
+	if rules == nil {
+		return
+	}
+
+	if s := rules.GetString(); s != nil {
+		applyStringRules(schema, s)
+		return
+	}
+
+	// The rules of the numeric types share the same field names
+	if oneof := rules.ProtoReflect().Descriptor().Oneofs().ByName("type"); oneof != nil {
+		if fd := rules.ProtoReflect().WhichOneof(oneof); fd != nil && fd.Kind() == protoreflect.MessageKind {
+			applyNumericRules(schema, rules.ProtoReflect().Get(fd).Message())
+		}
+	}
+}
+
+// applyStringRules applies the protovalidate string rules to the schema.
+func applyStringRules(schema *openapiSchema, rules *validate.StringRules) {
+	if rules.HasLen() {
+		schema.MinLength, schema.MaxLength = proto.Uint64(rules.GetLen()), proto.Uint64(rules.GetLen())
+	}
+	if rules.HasMinLen() {
+		schema.MinLength = proto.Uint64(rules.GetMinLen())
+	}
+	if rules.HasMaxLen() {
+		schema.MaxLength = proto.Uint64(rules.GetMaxLen())
+	}
+	if rules.HasPattern() {
+		schema.Pattern = rules.GetPattern()
+	} else if rules.HasPrefix() {
+		schema.Pattern = "^" + regexp.QuoteMeta(rules.GetPrefix())
+	}
+	for _, value := range rules.GetIn() {
+		schema.Enum = append(schema.Enum, value)
+	}
+
+	switch {
+	case rules.GetEmail():
+		schema.Format = "email"
+	case rules.GetHostname():
+		schema.Format = "hostname"
+	case rules.GetIpv4():
+		schema.Format = "ipv4"
+	case rules.GetIpv6():
+		schema.Format = "ipv6"
+	case rules.GetUri():
+		schema.Format = "uri"
+	case rules.GetUriRef():
+		schema.Format = "uri-reference"
+	case rules.GetUuid():
+		schema.Format = "uuid"
+	}
+}
+
+// applyNumericRules applies the protovalidate rules of the numeric types to the schema.
+func applyNumericRules(schema *openapiSchema, rules protoreflect.Message) {
+	bounds := map[protoreflect.Name]**float64{
+		"gte": &schema.Minimum,
+		"gt":  &schema.ExclusiveMinimum,
+		"lte": &schema.Maximum,
+		"lt":  &schema.ExclusiveMaximum,
+	}
+	for name, bound := range bounds {
+		if fd := rules.Descriptor().Fields().ByName(name); fd != nil && rules.Has(fd) {
+			if value, ok := numericValue(rules.Get(fd)); ok {
+				*bound = &value
+			}
+		}
+	}
+
+	if fd := rules.Descriptor().Fields().ByName("in"); fd != nil && fd.IsList() {
+		list := rules.Get(fd).List()
+		for i := 0; i < list.Len(); i++ {
+			if value, ok := numericValue(list.Get(i)); ok {
+				schema.Enum = append(schema.Enum, value)
+			}
+		}
+	}
+}
+
+// numericValue returns the value as a float64 if it is a number.
+func numericValue(v protoreflect.Value) (float64, bool) {
+	switch n := v.Interface().(type) {
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// applyRepeatedRules applies the protovalidate repeated rules to the schema.
+func applyRepeatedRules(schema *openapiSchema, rules *validate.RepeatedRules) {
+	if rules.HasMinItems() {
+		schema.MinItems = proto.Uint64(rules.GetMinItems())
+	}
+	if rules.HasMaxItems() {
+		schema.MaxItems = proto.Uint64(rules.GetMaxItems())
+	}
+	schema.UniqueItems = rules.GetUnique()
+}
+
+// applyMapRules applies the protovalidate map rules to the schema.
+func applyMapRules(schema *openapiSchema, rules *validate.MapRules) {
+	if rules.HasMinPairs() {
+		schema.MinProperties = proto.Uint64(rules.GetMinPairs())
+	}
+	if rules.HasMaxPairs() {
+		schema.MaxProperties = proto.Uint64(rules.GetMaxPairs())
+	}
+}
+
+// resolveField returns the field of the message at the dot-separated path.
+func resolveField(msg *protogen.Message, path string) (*protogen.Field, error) {
+	var field *protogen.Field
+	for _, elem := range strings.Split(path, ".") {
+		if msg == nil {
+			return nil, fmt.Errorf("no field %q found in %s", elem, field.Desc.Name())
+		}
+		if field = lookupField(msg, elem); field == nil {
+			return nil, fmt.Errorf("no field %q found in %s", elem, msg.Desc.Name())
+		}
+		msg = field.Message
+	}
+	return field, nil
+}
+
+// commentText returns the text of the comments without the leading spaces.
+func commentText(comments protogen.Comments) string {
+	lines := strings.Split(strings.TrimSpace(string(comments)), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// packageVersion returns the version component of the proto package, e.g. "v1"
+// of "library.v1", or "0.0.0" if the package is not versioned.
+func packageVersion(pkg protoreflect.FullName) string {
+	if name := string(pkg.Name()); regexp.MustCompile(`^v\d+`).MatchString(name) {
+		return name
+	}
+	return "0.0.0"
+}
+
+// openapiDocument is the subset of the OpenAPI 3.1 document used by the generator.
+type openapiDocument struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       openapiInfo                `json:"info"`
+	Tags       []openapiTag               `json:"tags,omitempty"`
+	Paths      map[string]openapiPathItem `json:"paths"`
+	Components openapiComponents          `json:"components"`
+}
+
+type openapiInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type openapiTag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// openapiPathItem maps the lower-case HTTP methods to the operations of a path.
+type openapiPathItem map[string]*openapiOperation
+
+type openapiOperation struct {
+	Tags        []string                    `json:"tags,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	OperationID string                      `json:"operationId"`
+	Parameters  []*openapiParameter         `json:"parameters,omitempty"`
+	RequestBody *openapiRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openapiResponse `json:"responses"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+}
+
+type openapiParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *openapiSchema `json:"schema"`
+}
+
+type openapiRequestBody struct {
+	Required bool                        `json:"required,omitempty"`
+	Content  map[string]openapiMediaType `json:"content"`
+}
+
+type openapiMediaType struct {
+	Schema *openapiSchema `json:"schema"`
+}
+
+type openapiResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openapiMediaType `json:"content,omitempty"`
+}
+
+type openapiComponents struct {
+	Schemas map[string]*openapiSchema `json:"schemas,omitempty"`
+}
+
+type openapiSchema struct {
+	Ref                  string            `json:"$ref,omitempty"`
+	AllOf                []*openapiSchema  `json:"allOf,omitempty"`
+	Type                 string            `json:"type,omitempty"`
+	Format               string            `json:"format,omitempty"`
+	Description          string            `json:"description,omitempty"`
+	Enum                 []any             `json:"enum,omitempty"`
+	Items                *openapiSchema    `json:"items,omitempty"`
+	Properties           openapiProperties `json:"properties,omitempty"`
+	AdditionalProperties *openapiSchema    `json:"additionalProperties,omitempty"`
+	Required             []string          `json:"required,omitempty"`
+	ReadOnly             bool              `json:"readOnly,omitempty"`
+	WriteOnly            bool              `json:"writeOnly,omitempty"`
+	Deprecated           bool              `json:"deprecated,omitempty"`
+	Pattern              string            `json:"pattern,omitempty"`
+	MinLength            *uint64           `json:"minLength,omitempty"`
+	MaxLength            *uint64           `json:"maxLength,omitempty"`
+	MinItems             *uint64           `json:"minItems,omitempty"`
+	MaxItems             *uint64           `json:"maxItems,omitempty"`
+	UniqueItems          bool              `json:"uniqueItems,omitempty"`
+	MinProperties        *uint64           `json:"minProperties,omitempty"`
+	MaxProperties        *uint64           `json:"maxProperties,omitempty"`
+	Minimum              *float64          `json:"minimum,omitempty"`
+	Maximum              *float64          `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64          `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64          `json:"exclusiveMaximum,omitempty"`
+}
+
+// openapiProperties is the properties of a schema, which are encoded
+// in the order of the fields of the message.
+type openapiProperties []openapiProperty
+
+type openapiProperty struct {
+	Name   string
+	Schema *openapiSchema
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (ps openapiProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, p := range ps {
+		if i != 0 {
+			buf.WriteByte(',')
+		}
+
+		name, err := json.Marshal(p.Name)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := json.Marshal(p.Schema)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(schema)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,7 @@
+package pattern
+
+// The package is a copy of the internal/pattern package of the alchemy module,
+// which cannot be imported since protoc-gen-alchemy does not depend on it. The
+// import path of the black-box tests is rewritten to the one of the copy.
+//go:generate cp ../../../../../internal/pattern/pattern.go ../../../../../internal/pattern/pattern_internal_test.go .
+//go:generate sh -c "sed 's#github.com/wjiec/alchemy/internal/pattern#github.com/wjiec/alchemy/cmd/protoc-gen-alchemy/internal/gengo/pattern#' ../../../../../internal/pattern/pattern_test.go > pattern_test.go"
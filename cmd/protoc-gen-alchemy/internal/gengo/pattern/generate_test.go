@@ -0,0 +1,28 @@
+package pattern
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	// The import path of the black-box tests is rewritten by go generate.
+	rewrite := strings.NewReplacer("github.com/wjiec/alchemy/internal/pattern",
+		"github.com/wjiec/alchemy/cmd/protoc-gen-alchemy/internal/gengo/pattern")
+
+	for _, name := range []string{"pattern.go", "pattern_test.go", "pattern_internal_test.go"} {
+		t.Run(name, func(t *testing.T) {
+			want, err := os.ReadFile(filepath.Join("..", "..", "..", "..", "..", "internal", "pattern", name))
+			require.NoError(t, err, "the internal/pattern package of the alchemy module is required")
+
+			got, err := os.ReadFile(name)
+			require.NoError(t, err)
+			assert.Equal(t, rewrite.Replace(string(want)), string(got), "%s is out of sync, run go generate", name)
+		})
+	}
+}
@@ -1,51 +1,354 @@
+// Package pattern parses the URL path templates of the HttpRules.
+//
+// The package is shared by the alchemy module and protoc-gen-alchemy, the copy in
+// cmd/protoc-gen-alchemy/internal/gengo/pattern is kept in sync by go generate.
 package pattern
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 )
 
-// Parse extracts parameter names from a URL path pattern with braces.
+// Template represents a parsed URL path template of an HttpRule.
+//
+// The grammar of the template is defined in google/api/http.proto:
 //
-// It identifies all parameters within braces like {name} or {name:regexp}.
+//	Template = "/" Segments [ Verb ] ;
+//	Segments = Segment { "/" Segment } ;
+//	Segment  = "*" | "**" | LITERAL | Variable ;
+//	Variable = "{" FieldPath [ "=" Segments ] "}" ;
+//	FieldPath = IDENT { "." IDENT } ;
+//	Verb     = ":" LITERAL ;
+//
+// For compatibility, the gorilla-style variables "{name:regexp}" are also accepted.
+type Template struct {
+	Segments []Segment
+	Verb     string
+}
+
+// Segment represents a single segment of a path template.
+type Segment struct {
+	Literal  string    // the literal text of the segment
+	Wildcard string    // "*" or "**" if the segment is a wildcard
+	Variable string    // the field path bound by the variable
+	Bindings []Segment // the segments matched by the variable, nil means a single "*"
+	Regexp   string    // the regexp of a gorilla-style variable
+}
+
+// WildcardVarPrefix is the prefix of the names of anonymous wildcard segments
+// in mux patterns, which cannot collide with field paths.
+const WildcardVarPrefix = "*"
+
+// ParseTemplate parses an HttpRule path template.
+//
+// It accepts the full template grammar of google/api/http.proto, including multi-segment
+// variable bindings like {name=projects/*/books/*}, wildcards and custom verbs, as well as
+// the gorilla-style variables like {name} or {name:regexp}.
+func ParseTemplate(template string) (*Template, error) {
+	if !strings.HasPrefix(template, "/") {
+		return nil, fmt.Errorf("alchemy: path template %q must start with '/'", template)
+	}
+
+	p := &templateParser{template: template}
+	path, verb, err := splitVerb(template[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	p.input = path
+	segments, err := p.parseSegments(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.input) != 0 {
+		return nil, p.errorf("unexpected %q", p.input)
+	}
+
+	return &Template{Segments: segments, Verb: verb}, nil
+}
+
+// Parse extracts the field paths bound by the variables of an HttpRule path template.
 func Parse(pattern string) ([]string, error) {
-	pairs, err := bracePairs(pattern)
+	template, err := ParseTemplate(pattern)
 	if err != nil {
 		return nil, err
 	}
 
-	names := make([]string, 0, len(pairs)/2)
-	for i := 0; i < len(pairs); i += 2 {
-		for colon := pairs[i] + 1; colon <= pairs[i+1]; colon++ {
-			if pattern[colon] == ':' || colon == pairs[i+1] {
-				names = append(names, pattern[pairs[i]+1:colon])
-				break
+	return template.Variables(), nil
+}
+
+// HasVerb reports whether the template ends with a custom verb.
+func (t *Template) HasVerb() bool {
+	return len(t.Verb) != 0
+}
+
+// Variables returns the field paths bound by the variables of the template.
+func (t *Template) Variables() []string {
+	names := make([]string, 0, len(t.Segments))
+	for _, segment := range t.Segments {
+		if len(segment.Variable) != 0 {
+			names = append(names, segment.Variable)
+		}
+	}
+	return names
+}
+
+// MuxPattern converts the template into a pattern accepted by the gorilla router.
+func (t *Template) MuxPattern() string {
+	var sb strings.Builder
+	for i, segment := range t.Segments {
+		sb.WriteByte('/')
+		switch {
+		case len(segment.Variable) != 0 && len(segment.Regexp) != 0:
+			sb.WriteString("{" + segment.Variable + ":" + segment.Regexp + "}")
+		case len(segment.Variable) != 0 && segment.Bindings == nil:
+			sb.WriteString("{" + segment.Variable + "}")
+		case len(segment.Variable) != 0:
+			sb.WriteString("{" + segment.Variable + ":" + segmentsRegexp(segment.Bindings) + "}")
+		case len(segment.Wildcard) != 0:
+			sb.WriteString(fmt.Sprintf("{%s%d:%s}", WildcardVarPrefix, i, segmentsRegexp(t.Segments[i:i+1])))
+		default:
+			sb.WriteString(segment.Literal)
+		}
+	}
+	if t.HasVerb() {
+		sb.WriteString(":" + t.Verb)
+	}
+
+	return sb.String()
+}
+
+// segmentsRegexp returns the regexp matching the segments.
+func segmentsRegexp(segments []Segment) string {
+	var sb strings.Builder
+	for i, segment := range segments {
+		switch segment.Wildcard {
+		case "*":
+			if i != 0 {
+				sb.WriteByte('/')
+			}
+			sb.WriteString("[^/]+")
+		case "**":
+			if i != 0 {
+				sb.WriteString("(?:/.*)?")
+			} else {
+				sb.WriteString(".*")
+			}
+		default:
+			if i != 0 {
+				sb.WriteByte('/')
 			}
+			sb.WriteString(regexp.QuoteMeta(segment.Literal))
 		}
 	}
+	return sb.String()
+}
 
-	return names, nil
+// Parameter describes a path parameter of an OpenAPI path template.
+type Parameter struct {
+	Name     string // the field path bound by the parameter
+	Template string // the segments matched by the parameter, e.g. "shelves/*"
+	Regexp   string // the regexp of a gorilla-style variable
 }
 
-// bracePairs finds all first matching pairs of braces in a string.
+// OpenAPIPath converts an HttpRule path template into an OpenAPI path template and
+// returns it with its parameters.
 //
-// It returns an error in case of unbalanced braces.
-func bracePairs(s string) ([]int, error) {
-	pairs, level := make([]int, 0, 16), 0
-	for i, left := 0, 0; i < len(s) && level >= 0; i++ {
-		switch s[i] {
+// The variables are replaced by their field paths, e.g. "/v1/{name=shelves/*}:publish"
+// becomes "/v1/{name}:publish", the anonymous wildcards are kept as-is.
+func OpenAPIPath(pattern string) (string, []Parameter, error) {
+	template, err := ParseTemplate(pattern)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var sb strings.Builder
+	var parameters []Parameter
+	for _, segment := range template.Segments {
+		sb.WriteByte('/')
+		if len(segment.Variable) == 0 {
+			sb.WriteString(segmentsText([]Segment{segment}))
+			continue
+		}
+
+		sb.WriteString("{" + segment.Variable + "}")
+		parameter := Parameter{Name: segment.Variable, Template: "*", Regexp: segment.Regexp}
+		if segment.Bindings != nil {
+			parameter.Template = segmentsText(segment.Bindings)
+		}
+		parameters = append(parameters, parameter)
+	}
+	if template.HasVerb() {
+		sb.WriteString(":" + template.Verb)
+	}
+
+	return sb.String(), parameters, nil
+}
+
+// segmentsText returns the text of the segments without variables.
+func segmentsText(segments []Segment) string {
+	texts := make([]string, len(segments))
+	for i, segment := range segments {
+		texts[i] = segment.Literal + segment.Wildcard
+	}
+	return strings.Join(texts, "/")
+}
+
+// splitVerb splits the custom verb from the path of the template.
+//
+// The verb is the text after the last colon of the last segment,
+// the colons within the variables are not taken into account.
+func splitVerb(template string) (string, string, error) {
+	colon, depth := -1, 0
+	for i := 0; i < len(template); i++ {
+		switch template[i] {
 		case '{':
-			if level++; level == 1 {
-				left = i
-			}
+			depth++
 		case '}':
-			if level--; level == 0 {
-				pairs = append(pairs, left, i)
+			if depth--; depth < 0 {
+				return "", "", fmt.Errorf("alchemy: unbalanced braces in %q", "/"+template)
+			}
+		case '/':
+			if depth == 0 {
+				colon = -1
+			}
+		case ':':
+			if depth == 0 {
+				colon = i
 			}
 		}
 	}
-	if level != 0 {
-		return nil, fmt.Errorf("alchemy: unbalanced braces in %q", s)
+	if depth != 0 {
+		return "", "", fmt.Errorf("alchemy: unbalanced braces in %q", "/"+template)
 	}
 
-	return pairs, nil
+	if colon < 0 {
+		return template, "", nil
+	}
+	if colon == len(template)-1 {
+		return "", "", fmt.Errorf("alchemy: empty verb in %q", "/"+template)
+	}
+	return template[:colon], template[colon+1:], nil
+}
+
+// templateParser is a recursive descent parser of the path templates.
+type templateParser struct {
+	template string
+	input    string
+}
+
+// parseSegments parses a list of segments separated by slashes.
+func (p *templateParser) parseSegments(nested bool) ([]Segment, error) {
+	var segments []Segment
+	for {
+		segment, err := p.parseSegment(nested)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(segments) != 0 && segments[len(segments)-1].Wildcard == "**" {
+			return nil, p.errorf("'**' must be the last segment")
+		}
+		segments = append(segments, segment)
+
+		if !strings.HasPrefix(p.input, "/") {
+			return segments, nil
+		}
+		p.input = p.input[1:]
+	}
+}
+
+// parseSegment parses a single segment.
+func (p *templateParser) parseSegment(nested bool) (Segment, error) {
+	switch {
+	case strings.HasPrefix(p.input, "**"):
+		p.input = p.input[2:]
+		return Segment{Wildcard: "**"}, nil
+	case strings.HasPrefix(p.input, "*"):
+		p.input = p.input[1:]
+		return Segment{Wildcard: "*"}, nil
+	case strings.HasPrefix(p.input, "{"):
+		if nested {
+			return Segment{}, p.errorf("nested variables are not allowed")
+		}
+		return p.parseVariable()
+	}
+
+	end := strings.IndexAny(p.input, "/{}*")
+	if end < 0 {
+		end = len(p.input)
+	}
+
+	literal := p.input[:end]
+	if p.input = p.input[end:]; len(literal) == 0 && (nested || len(p.input) != 0) {
+		return Segment{}, p.errorf("empty segment")
+	}
+	return Segment{Literal: literal}, nil
+}
+
+// parseVariable parses a variable enclosed in braces.
+func (p *templateParser) parseVariable() (Segment, error) {
+	end := strings.IndexAny(p.input, "=:}")
+	if end < 0 {
+		return Segment{}, p.errorf("unterminated variable")
+	}
+
+	segment := Segment{Variable: p.input[1:end]}
+	if !isFieldPath(segment.Variable) {
+		return Segment{}, p.errorf("invalid variable name %q", segment.Variable)
+	}
+
+	p.input = p.input[end:]
+	switch p.input[0] {
+	case '=':
+		p.input = p.input[1:]
+		bindings, err := p.parseSegments(true)
+		if err != nil {
+			return Segment{}, err
+		}
+		segment.Bindings = bindings
+	case ':':
+		end, depth := 1, 0
+		for ; end < len(p.input) && (p.input[end] != '}' || depth != 0); end++ {
+			switch p.input[end] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+		}
+		if segment.Regexp = p.input[1:end]; len(segment.Regexp) == 0 {
+			return Segment{}, p.errorf("empty regexp of variable %q", segment.Variable)
+		}
+		p.input = p.input[end:]
+	}
+
+	if !strings.HasPrefix(p.input, "}") {
+		return Segment{}, p.errorf("unterminated variable %q", segment.Variable)
+	}
+	p.input = p.input[1:]
+
+	return segment, nil
+}
+
+// errorf returns an error describing the syntax error at the current position.
+func (p *templateParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("alchemy: invalid path template %q at %d: %s",
+		p.template, len(p.template)-len(p.input), fmt.Sprintf(format, args...))
+}
+
+// isFieldPath reports whether s is a dot-separated list of identifiers.
+func isFieldPath(s string) bool {
+	for _, ident := range strings.Split(s, ".") {
+		if len(ident) == 0 {
+			return false
+		}
+		for i, c := range ident {
+			if !(c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i != 0 && '0' <= c && c <= '9') {
+				return false
+			}
+		}
+	}
+	return true
 }
@@ -0,0 +1,48 @@
+package libraryv1_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/wjiec/alchemy"
+	"github.com/wjiec/alchemy/alchemytest"
+	"github.com/wjiec/alchemy/bizerr"
+
+	libraryv1 "example.com/library/v1"
+)
+
+func TestLibraryServiceMock(t *testing.T) {
+	mock := new(libraryv1.LibraryServiceMock).OnGetBook(func(ctx context.Context, in *libraryv1.GetBookRequest) (*libraryv1.Book, error) {
+		return &libraryv1.Book{Name: in.GetName(), Title: "Dune"}, nil
+	})
+	srv := alchemytest.Start(t, alchemytest.WithAppOptions(
+		alchemy.WithServiceRegister(libraryv1.RegisterLibraryServiceAlchemyServer, libraryv1.LibraryServiceServer(mock)),
+	))
+	client := libraryv1.NewLibraryServiceHttpClient(
+		alchemy.NewHttpClient("http://"+alchemytest.Host, alchemy.HttpClientWithHttpClient(srv.Client)),
+	)
+
+	book, err := client.GetBook(context.Background(), &libraryv1.GetBookRequest{Name: "books/1", View: "full"})
+	require.NoError(t, err)
+	assert.Equal(t, "books/1", book.GetName())
+	assert.Equal(t, "Dune", book.GetTitle())
+	if calls := mock.GetBookCalls(); assert.Len(t, calls, 1) {
+		assert.Equal(t, "full", calls[0].GetView())
+	}
+
+	_, err = client.CreateBook(context.Background(), &libraryv1.CreateBookRequest{Book: &libraryv1.Book{Title: "Dune", PageCount: 412}})
+	var be *bizerr.Error
+	if assert.ErrorAs(t, err, &be) {
+		assert.Equal(t, uint32(http.StatusNotImplemented), be.Status())
+	}
+	assert.Len(t, mock.CreateBookCalls(), 1)
+
+	_, err = client.Ping(context.Background(), &libraryv1.GetBookRequest{})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
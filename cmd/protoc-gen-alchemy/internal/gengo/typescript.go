@@ -0,0 +1,490 @@
+package gengo
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/wjiec/alchemy/cmd/protoc-gen-alchemy/internal/gengo/pattern"
+)
+
+// TypeScriptRuntimeFile is the name of the module shared by the generated TypeScript
+// clients, which is generated at the root of the output directory.
+const TypeScriptRuntimeFile = "alchemy_runtime.ts"
+
+// GenerateTypeScriptRuntime generates the runtime module imported by the TypeScript
+// clients if any of the files to generate has services, it must be called once
+// after the files are generated by GenerateFile.
+//
+// The runtime is shared by all the clients, so that the AlchemyError thrown by
+// the clients of different files is the same class.
+func GenerateTypeScriptRuntime(gen *protogen.Plugin) {
+	for _, file := range gen.Files {
+		if file.Generate && len(file.Services) != 0 {
+			g := gen.NewGeneratedFile(TypeScriptRuntimeFile, "")
+			g.P("// Code generated by protoc-gen-alchemy. DO NOT EDIT.")
+			genVersionHeader(g, gen)
+			g.P(strings.TrimSpace(typeScriptRuntime))
+			return
+		}
+	}
+}
+
+// genTypeScript generates the TypeScript interfaces of the messages and the
+// fetch-based clients of the services of the file.
+//
+// The messages are typed by their JSON representation written by the JsonEncoder,
+// the fields are named by their JSON names and all of them are optional as the
+// unpopulated fields are omitted. The errors are thrown as AlchemyError with the
+// google.rpc.Status body written by the HTTP server, the types of the runtime
+// are re-exported from the module generated by GenerateTypeScriptRuntime.
+func genTypeScript(gen *protogen.Plugin, file *protogen.File) error {
+	b := &typeScriptBuilder{seen: make(map[protoreflect.FullName]bool)}
+
+	var clients strings.Builder
+	for _, service := range file.Services {
+		if err := b.client(&clients, service); err != nil {
+			return err
+		}
+	}
+
+	g := gen.NewGeneratedFile(file.GeneratedFilenamePrefix+".alchemy.ts", "")
+	genGeneratedHeader(g, file)
+	genVersionHeader(g, gen)
+	runtime := strconv.Quote(typeScriptRuntimeImport(file.GeneratedFilenamePrefix))
+	g.P("import * as alchemy from ", runtime, ";")
+	g.P()
+	g.P("export { AlchemyError } from ", runtime, ";")
+	g.P("export type { AlchemyAny, AlchemyClientOptions, AlchemyStatus } from ", runtime, ";")
+	g.P()
+	for i := 0; i < len(b.pending); i++ {
+		b.declaration(g, b.pending[i])
+	}
+	g.P(strings.TrimRight(clients.String(), "\n"))
+
+	return nil
+}
+
+// typeScriptRuntimeImport returns the import path of the runtime module relative
+// to the file generated with the prefix.
+func typeScriptRuntimeImport(prefix string) string {
+	module := strings.TrimSuffix(TypeScriptRuntimeFile, ".ts")
+	if dir := path.Dir(prefix); dir != "." {
+		return strings.Repeat("../", strings.Count(dir, "/")+1) + module
+	}
+	return "./" + module
+}
+
+// typeScriptBuilder collects the TypeScript declarations of the messages and the
+// enums referenced by the clients.
+type typeScriptBuilder struct {
+	seen    map[protoreflect.FullName]bool
+	pending []any // *protogen.Message or *protogen.Enum to be declared
+}
+
+// client writes the client class of the service, which has a method for each
+// HttpRule binding of the unary methods.
+func (b *typeScriptBuilder) client(w *strings.Builder, service *protogen.Service) error {
+	writeJSDoc(w, "", commentText(service.Comments.Leading), isDeprecated(service.Desc.Options()))
+	fmt.Fprintf(w, "export class %sClient {\n", service.GoName)
+	fmt.Fprintf(w, "  constructor(private readonly options: alchemy.AlchemyClientOptions = {}) {}\n")
+
+	for _, method := range service.Methods {
+		if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+			continue
+		}
+
+		index := 0
+		for rule := range visitHttpRules(method.Desc.Options()) {
+			if err := b.clientMethod(w, method, rule, index); err != nil {
+				return err
+			}
+			index++
+		}
+	}
+	w.WriteString("}\n\n")
+
+	return nil
+}
+
+// clientMethod writes the client method of the method bound by the HttpRule.
+func (b *typeScriptBuilder) clientMethod(w *strings.Builder, method *protogen.Method, rule *annotations.HttpRule, index int) error {
+	httpMethod, pathPattern := parseMethodWithPattern(rule)
+	openapiPath, pathParameters, err := pattern.OpenAPIPath(pathPattern)
+	if err != nil {
+		return err
+	}
+
+	path := strings.NewReplacer("\\", "\\\\", "`", "\\`", "$", "\\$").Replace(openapiPath)
+	excluded := make(map[string]bool)
+	for _, parameter := range pathParameters {
+		accessor, err := jsonAccessor(method.Input, parameter.Name)
+		if err != nil {
+			return err
+		}
+
+		multiSegment := strings.Contains(parameter.Template, "/") || strings.Contains(parameter.Template, "**")
+		path = strings.Replace(path, "{"+parameter.Name+"}",
+			fmt.Sprintf("${alchemy.pathParam(request%s, %s, %t)}", accessor, strconv.Quote(parameter.Name), multiSegment), 1)
+		excluded[parameter.Name] = true
+	}
+
+	body, multipart := "undefined", false
+	switch rule.Body {
+	case "":
+	case "*":
+		body, multipart = "request", hasMultipartField(method.Input)
+	default:
+		accessor, err := jsonAccessor(method.Input, rule.Body)
+		if err != nil {
+			return err
+		}
+		if field, err := resolveField(method.Input, rule.Body); err == nil && field.Message != nil {
+			multipart = hasMultipartField(field.Message)
+		}
+		body = "request" + accessor
+		excluded[rule.Body] = true
+	}
+	if rule.Body != "*" {
+		var names []string
+		visitQueryFields(method.Input, "", "", excluded, nil, func(field *protogen.Field, jsonName string) {
+			names = append(names, strconv.Quote(jsonName))
+		})
+		if len(names) != 0 {
+			path += "${alchemy.query(request, [" + strings.Join(names, ", ") + "])}"
+		}
+	}
+
+	name := unexport(method.GoName)
+	if index != 0 {
+		name += fmt.Sprintf("_%d", index)
+	}
+	binary := isBinaryMessage(method.Output.Desc.FullName())
+
+	w.WriteString("\n")
+	writeJSDoc(w, "  ", commentText(method.Comments.Leading), isDeprecated(method.Desc.Options()))
+	fmt.Fprintf(w, "  %s(request: %s, init?: RequestInit): Promise<%s> {\n", name, b.messageType(method.Input), b.messageType(method.Output))
+	fmt.Fprintf(w, "    return alchemy.send(this.options, %s, `%s`, %s, %t, %t, init);\n", strconv.Quote(httpMethod), path, body, multipart, binary)
+	w.WriteString("  }\n")
+
+	return nil
+}
+
+// declaration writes the declaration of the message or the enum.
+func (b *typeScriptBuilder) declaration(g *protogen.GeneratedFile, v any) {
+	var w strings.Builder
+	switch v := v.(type) {
+	case *protogen.Message:
+		writeJSDoc(&w, "", commentText(v.Comments.Leading), isDeprecated(v.Desc.Options()))
+		fmt.Fprintf(&w, "export interface %s {\n", v.GoIdent.GoName)
+		for _, field := range v.Fields {
+			writeJSDoc(&w, "  ", commentText(field.Comments.Leading), isDeprecated(field.Desc.Options()))
+			fmt.Fprintf(&w, "  %s?: %s;\n", propertyName(field.Desc.JSONName()), b.fieldType(field))
+		}
+		w.WriteString("}\n")
+	case *protogen.Enum:
+		values := make([]string, len(v.Values))
+		for i, value := range v.Values {
+			values[i] = strconv.Quote(string(value.Desc.Name()))
+		}
+		writeJSDoc(&w, "", commentText(v.Comments.Leading), isDeprecated(v.Desc.Options()))
+		fmt.Fprintf(&w, "export type %s = %s;\n", v.GoIdent.GoName, strings.Join(values, " | "))
+	}
+	g.P(w.String())
+}
+
+// fieldType returns the type of the field.
+func (b *typeScriptBuilder) fieldType(field *protogen.Field) string {
+	switch {
+	case field.Desc.IsMap():
+		return "{ [key: string]: " + b.valueType(field.Message.Fields[1]) + " }"
+	case field.Desc.IsList():
+		if valueType := b.valueType(field); !strings.ContainsAny(valueType, " |") {
+			return valueType + "[]"
+		} else {
+			return "(" + valueType + ")[]"
+		}
+	default:
+		return b.valueType(field)
+	}
+}
+
+// valueType returns the type of a single value of the field.
+func (b *typeScriptBuilder) valueType(field *protogen.Field) string {
+	switch field.Desc.Kind() {
+	case protoreflect.BoolKind:
+		return "boolean"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.FloatKind, protoreflect.DoubleKind:
+		return "number"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind, protoreflect.StringKind, protoreflect.BytesKind:
+		// The 64-bit integers are encoded as strings to keep their precision.
+		return "string"
+	case protoreflect.EnumKind:
+		return b.enumType(field.Enum)
+	default:
+		return b.messageType(field.Message)
+	}
+}
+
+// messageType returns the type of the message, the messages which are not
+// well-known types are declared by their Go names.
+func (b *typeScriptBuilder) messageType(msg *protogen.Message) string {
+	if typ, ok := wellKnownTypeScriptType(msg.Desc.FullName()); ok {
+		return typ
+	}
+
+	if !b.seen[msg.Desc.FullName()] {
+		b.seen[msg.Desc.FullName()] = true
+		b.pending = append(b.pending, msg)
+	}
+	return msg.GoIdent.GoName
+}
+
+// enumType returns the type of the enum, which is a union of the names of its values.
+func (b *typeScriptBuilder) enumType(enum *protogen.Enum) string {
+	if enum.Desc.FullName() == "google.protobuf.NullValue" {
+		return "null"
+	}
+
+	if !b.seen[enum.Desc.FullName()] {
+		b.seen[enum.Desc.FullName()] = true
+		b.pending = append(b.pending, enum)
+	}
+	return enum.GoIdent.GoName
+}
+
+// wellKnownTypeScriptType returns the type of the well-known types and the messages
+// handled by alchemy, which have special JSON representations.
+func wellKnownTypeScriptType(name protoreflect.FullName) (string, bool) {
+	switch name {
+	case "google.protobuf.Timestamp", "google.protobuf.Duration", "google.protobuf.FieldMask",
+		"google.protobuf.StringValue", "google.protobuf.BytesValue",
+		"google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		return "string", true
+	case "google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.FloatValue", "google.protobuf.DoubleValue":
+		return "number", true
+	case "google.protobuf.BoolValue":
+		return "boolean", true
+	case "google.protobuf.Struct":
+		return "{ [key: string]: unknown }", true
+	case "google.protobuf.Empty":
+		return "Record<string, never>", true
+	case "google.protobuf.Value":
+		return "unknown", true
+	case "google.protobuf.ListValue":
+		return "unknown[]", true
+	case "google.protobuf.Any":
+		return "alchemy.AlchemyAny", true
+	case httpBodyMessage, downloadMessage:
+		return "Blob", true
+	case multipartMessage:
+		return "Blob[]", true
+	}
+	return "", false
+}
+
+// jsonAccessor returns the optional chaining accessor of the field at the
+// dot-separated proto path by the JSON names, e.g. ".book?.name".
+func jsonAccessor(msg *protogen.Message, path string) (string, error) {
+	var names []string
+	for _, elem := range strings.Split(path, ".") {
+		if msg == nil {
+			return "", fmt.Errorf("no field %q found in %s", elem, path)
+		}
+		field := lookupField(msg, elem)
+		if field == nil {
+			return "", fmt.Errorf("no field %q found in %s", elem, msg.Desc.Name())
+		}
+		names = append(names, field.Desc.JSONName())
+		msg = field.Message
+	}
+	return "." + strings.Join(names, "?."), nil
+}
+
+// propertyName returns the name of the property, quoted if it is not an identifier.
+func propertyName(name string) string {
+	for i, r := range name {
+		if !(r == '_' || r == '$' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || i != 0 && '0' <= r && r <= '9') {
+			return strconv.Quote(name)
+		}
+	}
+	return name
+}
+
+// writeJSDoc writes the text as a JSDoc comment with the indent.
+func writeJSDoc(w *strings.Builder, indent, text string, deprecated bool) {
+	var lines []string
+	if len(text) != 0 {
+		lines = strings.Split(strings.ReplaceAll(text, "*/", "*\\/"), "\n")
+	}
+	if deprecated {
+		lines = append(lines, "@deprecated")
+	}
+
+	switch len(lines) {
+	case 0:
+	case 1:
+		w.WriteString(indent + "/** " + lines[0] + " */\n")
+	default:
+		w.WriteString(indent + "/**\n")
+		for _, line := range lines {
+			w.WriteString(strings.TrimRight(indent+" * "+line, " ") + "\n")
+		}
+		w.WriteString(indent + " */\n")
+	}
+}
+
+// isDeprecated reports whether the options of the descriptor mark it as deprecated.
+func isDeprecated(options any) bool {
+	deprecated, _ := options.(interface{ GetDeprecated() bool })
+	return deprecated != nil && deprecated.GetDeprecated()
+}
+
+// typeScriptRuntime is the runtime module shared by the generated clients.
+const typeScriptRuntime = `
+/** AlchemyAny is the JSON representation of a google.protobuf.Any message. */
+export interface AlchemyAny {
+  "@type": string;
+  [key: string]: unknown;
+}
+
+/** AlchemyStatus is the body of the error responses, a google.rpc.Status message. */
+export interface AlchemyStatus {
+  code?: number;
+  message?: string;
+  details?: AlchemyAny[];
+}
+
+/** AlchemyError is thrown by the clients if the server responds with an error. */
+export class AlchemyError extends Error {
+  constructor(
+    readonly httpStatus: number,
+    readonly status: AlchemyStatus,
+  ) {
+    super(status.message ?? "HTTP " + httpStatus);
+    this.name = "AlchemyError";
+  }
+
+  /** The gRPC status code of the error. */
+  get code(): number {
+    return this.status.code ?? 2;
+  }
+}
+
+/** AlchemyClientOptions configures the clients. */
+export interface AlchemyClientOptions {
+  /** The base URL of the server, e.g. "https://api.example.com". */
+  baseUrl?: string;
+  /** The fetch function used to send the requests, the global fetch by default. */
+  fetch?: typeof fetch;
+  /** The headers sent with all the requests. */
+  headers?: HeadersInit;
+}
+
+/** pathParam encodes the path parameter, it is used by the generated clients. */
+export function pathParam(value: unknown, name: string, multiSegment: boolean): string {
+  if (value === undefined || value === null || value === "") {
+    throw new Error("alchemy: missing path parameter " + name);
+  }
+  const text = String(value);
+  return multiSegment ? text.split("/").map(encodeURIComponent).join("/") : encodeURIComponent(text);
+}
+
+/** query encodes the query parameters, it is used by the generated clients. */
+export function query(request: object, names: string[]): string {
+  const params = new URLSearchParams();
+  for (const name of names) {
+    let value: unknown = request;
+    for (const key of name.split(".")) {
+      value = value === undefined || value === null ? undefined : (value as Record<string, unknown>)[key];
+    }
+    for (const item of Array.isArray(value) ? value : [value]) {
+      if (item !== undefined && item !== null) {
+        params.append(name, String(item));
+      }
+    }
+  }
+  const text = params.toString();
+  return text.length === 0 ? "" : "?" + text;
+}
+
+/**
+ * formData encodes the request as a multipart form, the Blob fields are sent as
+ * the files and the other fields as the values named by their dotted paths.
+ */
+function formData(body: unknown): FormData {
+  const form = new FormData();
+  const append = (name: string, value: unknown): void => {
+    if (value === undefined || value === null) {
+      return;
+    }
+    if (value instanceof Blob) {
+      form.append(name, value);
+    } else if (Array.isArray(value)) {
+      value.forEach((item) => append(name, item));
+    } else if (typeof value === "object") {
+      for (const [key, item] of Object.entries(value)) {
+        append(name.length === 0 ? key : name + "." + key, item);
+      }
+    } else {
+      form.append(name, String(value));
+    }
+  };
+  append("", body);
+  return form;
+}
+
+/** send sends the request and decodes the response, it is used by the generated clients. */
+export async function send<T>(
+  options: AlchemyClientOptions,
+  method: string,
+  path: string,
+  body: unknown,
+  multipart: boolean,
+  binary: boolean,
+  init?: RequestInit,
+): Promise<T> {
+  const headers = new Headers(options.headers);
+  new Headers(init?.headers).forEach((value, key) => headers.set(key, value));
+
+  let payload: BodyInit | undefined;
+  if (body instanceof Blob) {
+    payload = body;
+    if (body.type.length !== 0) {
+      headers.set("Content-Type", body.type);
+    }
+  } else if (multipart) {
+    // The Content-Type with the boundary is set by fetch.
+    payload = formData(body);
+    headers.delete("Content-Type");
+  } else if (body !== undefined) {
+    payload = JSON.stringify(body);
+    headers.set("Content-Type", "application/json");
+  }
+
+  const response = await (options.fetch ?? fetch)((options.baseUrl ?? "") + path, { ...init, method, headers, body: payload });
+  if (!response.ok) {
+    let status: AlchemyStatus;
+    try {
+      status = (await response.json()) as AlchemyStatus;
+    } catch {
+      status = { code: 2, message: response.statusText };
+    }
+    throw new AlchemyError(response.status, status);
+  }
+  if (binary) {
+    return (await response.blob()) as T;
+  }
+
+  const text = await response.text();
+  return (text.length === 0 ? {} : JSON.parse(text)) as T;
+}
+`
@@ -9,6 +9,11 @@ import (
 )
 
 func main() {
+	openapi := flag.Bool("openapi", false, "generate the OpenAPI 3.1 documents of the services")
+	httpClient := flag.Bool("http_client", false, "generate the HTTP clients of the services")
+	typeScript := flag.Bool("typescript", false, "generate the TypeScript types and clients of the services")
+	mock := flag.Bool("mock", false, "generate the stubs and the mocks of the services")
+
 	protogen.Options{ParamFunc: flag.CommandLine.Set}.Run(func(gen *protogen.Plugin) error {
 		gen.SupportedFeatures = gengo.SupportedFeatures
 		for _, file := range gen.Files {
@@ -16,10 +21,13 @@ func main() {
 				continue
 			}
 
-			if err := gengo.GenerateFile(gen, file); err != nil {
+			if err := gengo.GenerateFile(gen, file, gengo.Options{OpenAPI: *openapi, HttpClient: *httpClient, TypeScript: *typeScript, Mock: *mock}); err != nil {
 				return err
 			}
 		}
+		if *typeScript {
+			gengo.GenerateTypeScriptRuntime(gen)
+		}
 		return nil
 	})
 }
@@ -3,8 +3,17 @@ package alchemy
 import (
 	"errors"
 	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/wjiec/alchemy/bizerr"
 )
 
+// ErrRequestBodyTooLarge is returned by decoders when the request body exceeds
+// the size limit configured for the server or the route.
+var ErrRequestBodyTooLarge = bizerr.New(uint32(codes.ResourceExhausted), http.StatusRequestEntityTooLarge, "request body too large")
+
 // DecoderFactory defines an interface for creating HTTP request decoders.
 type DecoderFactory interface {
 	// Decoder creates a function that can decode a request data into a given value.
@@ -74,3 +83,12 @@ func (h *httpDynamicCodec) Encoder(w http.ResponseWriter, r *http.Request) func(
 		return nil, errors.New("no encoder factory found")
 	}
 }
+
+// decodeError converts an error that occurred while reading the request into an error
+// reported to the client, body size violations are reported as [ErrRequestBodyTooLarge].
+func decodeError(err error) error {
+	if maxBytesErr := new(http.MaxBytesError); errors.As(err, &maxBytesErr) {
+		return ErrRequestBodyTooLarge
+	}
+	return status.Errorf(codes.InvalidArgument, "%v", err)
+}
@@ -1,41 +1,138 @@
 package alchemy
 
 import (
+	"bytes"
 	"io"
 	"net/http"
+	"reflect"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 // JsonDecoder implements request bodies JSON decoding for HTTP requests.
+//
+// Requests targeting a [httpbody.HttpBody] message are passed through as is,
+// regardless of their content type.
 type JsonDecoder struct {
 	runtime.JSONPb
 }
 
 // Decoder returns a function that decodes JSON data from an HTTP request body into the provided value.
 //
-// It handles field selection based on the route description's RequestField.
+// It handles field selection based on the route description's RequestField. For PATCH
+// requests whose body is a single field, the only [fieldmaskpb.FieldMask] field of the
+// request message is populated from the keys present in the body if the client didn't
+// send one, as grpc-gateway does.
 func (j *JsonDecoder) Decoder(req *http.Request) func(any) error {
 	desc, _ := RouteDescFromContext(req.Context())
 	return func(raw any) error {
+		msg := raw
+		if len(desc.RequestField.Name) != 0 {
+			raw = desc.RequestField.Accessor(raw)
+		}
+
+		if body, ok := httpBodyOf(raw); ok {
+			data, err := io.ReadAll(req.Body)
+			if err != nil {
+				return decodeError(err)
+			}
+
+			body.ContentType = req.Header.Get("Content-Type")
+			body.Data = data
+			return nil
+		}
+
 		if req.Header.Get("Content-Type") != j.ContentType(raw) {
 			return nil
 		}
 
-		if len(desc.RequestField.Name) != 0 {
-			raw = desc.RequestField.Accessor(raw)
+		updateMask := patchUpdateMaskField(req, desc, msg)
+		if updateMask == nil {
+			if err := j.NewDecoder(req.Body).Decode(raw); err != nil && err != io.EOF {
+				return decodeError(err)
+			}
+			return nil
+		}
+
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return decodeError(err)
+		}
+		if err = j.NewDecoder(bytes.NewReader(data)).Decode(raw); err != nil && err != io.EOF {
+			return decodeError(err)
 		}
 
-		if err := j.NewDecoder(req.Body).Decode(raw); err != nil && err != io.EOF {
-			return status.Errorf(codes.InvalidArgument, "%v", err)
+		pm := msg.(proto.Message).ProtoReflect()
+		if mask, _ := pm.Get(updateMask).Message().Interface().(*fieldmaskpb.FieldMask); len(mask.GetPaths()) == 0 {
+			if body, ok := bodyMessageOf(raw); ok {
+				fieldMask, err := runtime.FieldMaskFromRequestBody(bytes.NewReader(data), body)
+				if err != nil {
+					return decodeError(err)
+				}
+				pm.Set(updateMask, protoreflect.ValueOfMessage(fieldMask.ProtoReflect()))
+			}
 		}
 		return nil
 	}
 }
 
+// bodyMessageOf returns the message the body is decoded into, the value returned by
+// the Accessor is either the message or a pointer to the message field.
+func bodyMessageOf(v any) (proto.Message, bool) {
+	body, ok := v.(proto.Message)
+	if !ok {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && !rv.IsNil() {
+			body, ok = rv.Elem().Interface().(proto.Message)
+		}
+	}
+
+	// The message is nil if the body is empty.
+	if !ok || !body.ProtoReflect().IsValid() {
+		return nil, false
+	}
+	return body, true
+}
+
+// fieldMaskFullName is the full name of the google.protobuf.FieldMask message.
+var fieldMaskFullName = (&fieldmaskpb.FieldMask{}).ProtoReflect().Descriptor().FullName()
+
+// patchUpdateMaskField returns the field of the request message that is populated from
+// the keys present in the body of a PATCH request, or nil if there is none.
+//
+// It is the only [fieldmaskpb.FieldMask] field of the message, and only applies
+// when the body is mapped to a single field of the message.
+func patchUpdateMaskField(req *http.Request, desc *RouteDesc, msg any) protoreflect.FieldDescriptor {
+	if req.Method != http.MethodPatch || len(desc.RequestField.Name) == 0 {
+		return nil
+	}
+
+	pm, ok := msg.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	var updateMask protoreflect.FieldDescriptor
+	fields := pm.ProtoReflect().Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if field.Message() != nil && field.Message().FullName() == fieldMaskFullName && !field.IsList() {
+			if updateMask != nil {
+				return nil
+			}
+			updateMask = field
+		}
+	}
+	return updateMask
+}
+
 // JsonEncoder implements response encoding to JSON format for HTTP responses.
+//
+// Responses of [httpbody.HttpBody] messages are written as is with their own content type,
+// including the HttpBody field selected by the response_body of the route.
 type JsonEncoder struct {
 	runtime.JSONPb
 }
@@ -43,7 +140,36 @@ type JsonEncoder struct {
 // Encoder returns a function that encodes a value as JSON in an HTTP response.
 func (j *JsonEncoder) Encoder(w http.ResponseWriter, _ *http.Request) func(any) ([]byte, error) {
 	return func(resp any) ([]byte, error) {
+		if body, ok := resp.(*httpbody.HttpBody); ok {
+			w.Header().Set("Content-Type", body.GetContentType())
+			return body.GetData(), nil
+		}
+
 		w.Header().Set("Content-Type", j.ContentType(resp))
 		return j.Marshal(resp)
 	}
 }
+
+// httpBodyOf returns the [httpbody.HttpBody] message referenced by v, allocating
+// it if v is a pointer to an unset message field.
+func httpBodyOf(v any) (*httpbody.HttpBody, bool) {
+	switch body := v.(type) {
+	case *httpbody.HttpBody:
+		return body, true
+	case **httpbody.HttpBody:
+		if *body == nil {
+			*body = new(httpbody.HttpBody)
+		}
+		return *body, true
+	}
+	return nil, false
+}
+
+// isHttpBody reports whether v references a [httpbody.HttpBody] message.
+func isHttpBody(v any) bool {
+	switch v.(type) {
+	case *httpbody.HttpBody, **httpbody.HttpBody:
+		return true
+	}
+	return false
+}
@@ -2,10 +2,14 @@ package alchemy_test
 
 import (
 	"bytes"
+	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 
 	"github.com/wjiec/alchemy"
 	"github.com/wjiec/alchemy/internal/testpb"
@@ -49,4 +53,197 @@ func TestJsonDecoder_Decoder(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("too large", func(t *testing.T) {
+		body := []byte(`{"string_value": "foo", "int64_value": 42}`)
+		req := NewRequest(
+			WithBody(http.MaxBytesReader(httptest.NewRecorder(), io.NopCloser(bytes.NewReader(body)), 8)),
+			WithHeader(http.Header{"Content-Type": []string{factory.ContentType(nil)}}),
+		)
+
+		if dec := factory.Decoder(req); assert.NotNil(t, dec) {
+			assert.ErrorIs(t, dec(&testpb.Proto3Message{}), alchemy.ErrRequestBodyTooLarge)
+		}
+	})
+}
+
+func TestJsonDecoder_HttpBody(t *testing.T) {
+	factory := alchemy.JsonDecoder{}
+
+	t.Run("message", func(t *testing.T) {
+		req := NewRequest(
+			WithBody(bytes.NewReader([]byte(`<xml>hello</xml>`))),
+			WithHeader(http.Header{"Content-Type": []string{"application/xml"}}),
+		)
+
+		if dec := factory.Decoder(req); assert.NotNil(t, dec) {
+			var body httpbody.HttpBody
+			if err := dec(&body); assert.NoError(t, err) {
+				assert.Equal(t, "application/xml", body.ContentType)
+				assert.Equal(t, []byte(`<xml>hello</xml>`), body.Data)
+			}
+		}
+	})
+
+	t.Run("field", func(t *testing.T) {
+		type Webhook struct{ Body *httpbody.HttpBody }
+		req := NewRequest(
+			WithBody(bytes.NewReader([]byte(`{"event": "push"}`))),
+			WithRouteDesc(&alchemy.RouteDesc{
+				RequestField: alchemy.KeyPath{
+					Name:     "body",
+					Accessor: func(a any) any { return &a.(*Webhook).Body },
+				},
+			}),
+			WithHeader(http.Header{"Content-Type": []string{"application/json; charset=utf-8"}}),
+		)
+
+		if dec := factory.Decoder(req); assert.NotNil(t, dec) {
+			var webhook Webhook
+			if err := dec(&webhook); assert.NoError(t, err) && assert.NotNil(t, webhook.Body) {
+				assert.Equal(t, "application/json; charset=utf-8", webhook.Body.ContentType)
+				assert.Equal(t, []byte(`{"event": "push"}`), webhook.Body.Data)
+			}
+		}
+	})
+}
+
+func TestJsonEncoder_Encoder(t *testing.T) {
+	factory := alchemy.JsonEncoder{}
+
+	t.Run("message", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		if enc := factory.Encoder(w, NewRequest()); assert.NotNil(t, enc) {
+			buf, err := enc(&testpb.Proto3Message{StringValue: "foo"})
+			if assert.NoError(t, err) {
+				assert.JSONEq(t, `{"stringValue": "foo"}`, string(buf))
+				assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			}
+		}
+	})
+
+	t.Run("http body", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		if enc := factory.Encoder(w, NewRequest()); assert.NotNil(t, enc) {
+			buf, err := enc(&httpbody.HttpBody{ContentType: "image/png", Data: []byte{0x89, 'P', 'N', 'G'}})
+			if assert.NoError(t, err) {
+				assert.Equal(t, []byte{0x89, 'P', 'N', 'G'}, buf)
+				assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+			}
+		}
+	})
+}
+
+func TestJsonDecoder_UpdateMask(t *testing.T) {
+	factory := alchemy.JsonDecoder{}
+	nested := alchemy.KeyPath{
+		Name:     "nested_value",
+		Accessor: func(a any) any { return &a.(*testpb.Proto3Message).NestedValue },
+	}
+
+	t.Run("from body", func(t *testing.T) {
+		req := NewRequest(
+			WithMethod(http.MethodPatch),
+			WithBody(bytes.NewReader([]byte(`{"stringValue": "foo", "int64_value": 42}`))),
+			WithRouteDesc(&alchemy.RouteDesc{RequestField: nested}),
+			WithHeader(http.Header{"Content-Type": []string{factory.ContentType(nil)}}),
+		)
+
+		if dec := factory.Decoder(req); assert.NotNil(t, dec) {
+			var message testpb.Proto3Message
+			if err := dec(&message); assert.NoError(t, err) {
+				assert.Equal(t, "foo", message.NestedValue.StringValue)
+				assert.ElementsMatch(t, []string{"string_value", "int64_value"}, message.UpdateMask.GetPaths())
+			}
+		}
+	})
+
+	t.Run("message accessor", func(t *testing.T) {
+		req := NewRequest(
+			WithMethod(http.MethodPatch),
+			WithBody(bytes.NewReader([]byte(`{"stringValue": "foo"}`))),
+			WithRouteDesc(&alchemy.RouteDesc{RequestField: alchemy.KeyPath{
+				Name: "nested_value",
+				Accessor: func(a any) any {
+					message := a.(*testpb.Proto3Message)
+					message.NestedValue = &testpb.Proto3Message{}
+					return message.NestedValue
+				},
+			}}),
+			WithHeader(http.Header{"Content-Type": []string{factory.ContentType(nil)}}),
+		)
+
+		if dec := factory.Decoder(req); assert.NotNil(t, dec) {
+			var message testpb.Proto3Message
+			if err := dec(&message); assert.NoError(t, err) {
+				assert.Equal(t, "foo", message.NestedValue.StringValue)
+				assert.Equal(t, []string{"string_value"}, message.UpdateMask.GetPaths())
+			}
+		}
+	})
+
+	t.Run("empty body", func(t *testing.T) {
+		req := NewRequest(
+			WithMethod(http.MethodPatch),
+			WithRouteDesc(&alchemy.RouteDesc{RequestField: alchemy.KeyPath{
+				Name:     "nested_value",
+				Accessor: func(a any) any { return a.(*testpb.Proto3Message).NestedValue },
+			}}),
+			WithHeader(http.Header{"Content-Type": []string{factory.ContentType(nil)}}),
+		)
+
+		if dec := factory.Decoder(req); assert.NotNil(t, dec) {
+			var message testpb.Proto3Message
+			if err := dec(&message); assert.NoError(t, err) {
+				assert.Nil(t, message.UpdateMask)
+			}
+		}
+	})
+
+	t.Run("client provided", func(t *testing.T) {
+		req := NewRequest(
+			WithMethod(http.MethodPatch),
+			WithBody(bytes.NewReader([]byte(`{"string_value": "foo", "int64_value": 42}`))),
+			WithRouteDesc(&alchemy.RouteDesc{RequestField: nested}),
+			WithHeader(http.Header{"Content-Type": []string{factory.ContentType(nil)}}),
+		)
+
+		if dec := factory.Decoder(req); assert.NotNil(t, dec) {
+			message := testpb.Proto3Message{UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"string_value"}}}
+			if err := dec(&message); assert.NoError(t, err) {
+				assert.Equal(t, []string{"string_value"}, message.UpdateMask.GetPaths())
+			}
+		}
+	})
+
+	t.Run("not patch", func(t *testing.T) {
+		req := NewRequest(
+			WithMethod(http.MethodPut),
+			WithBody(bytes.NewReader([]byte(`{"string_value": "foo"}`))),
+			WithRouteDesc(&alchemy.RouteDesc{RequestField: nested}),
+			WithHeader(http.Header{"Content-Type": []string{factory.ContentType(nil)}}),
+		)
+
+		if dec := factory.Decoder(req); assert.NotNil(t, dec) {
+			var message testpb.Proto3Message
+			if err := dec(&message); assert.NoError(t, err) {
+				assert.Nil(t, message.UpdateMask)
+			}
+		}
+	})
+
+	t.Run("whole body", func(t *testing.T) {
+		req := NewRequest(
+			WithMethod(http.MethodPatch),
+			WithBody(bytes.NewReader([]byte(`{"string_value": "foo"}`))),
+			WithHeader(http.Header{"Content-Type": []string{factory.ContentType(nil)}}),
+		)
+
+		if dec := factory.Decoder(req); assert.NotNil(t, dec) {
+			var message testpb.Proto3Message
+			if err := dec(&message); assert.NoError(t, err) {
+				assert.Nil(t, message.UpdateMask)
+			}
+		}
+	})
 }
@@ -1,36 +1,53 @@
 package alchemy
 
 import (
+	"context"
+	"maps"
+	"mime"
 	"net/http"
-	"strconv"
 	"strings"
-	"unsafe"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
+
+	"github.com/wjiec/alchemy/multipart"
 )
 
+// DefaultMultipartMaxMemory is the default number of bytes of a multipart form kept
+// in memory, the remainder of the file parts are spilled to temporary files.
+const DefaultMultipartMaxMemory = 32 << 20
+
 // MultipartCodec implements request multipart form data decoding for HTTP requests.
 type MultipartCodec struct{}
 
 // Decoder returns a function that extracts multipart form data from an HTTP
 // request and decodes it into the provided value.
+//
+// The uploaded files are registered to the request-scoped [multipart.Uploads] and
+// referenced by the [multipart.Multipart] fields named after the form keys, the
+// "<field>.ptr" form keys of the earlier versions are accepted as well.
+// The temporary files created while parsing the form are removed by the
+// HTTP server after the handler returns.
 func (m *MultipartCodec) Decoder(req *http.Request) func(any) error {
 	desc, _ := RouteDescFromContext(req.Context())
 	return func(raw any) error {
-		if req.Header.Get("Content-Type") != "multipart/form-data" {
+		if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType != "multipart/form-data" {
 			return nil
 		}
-		if err := req.ParseMultipartForm(32 << 20); err != nil {
-			return status.Errorf(codes.InvalidArgument, "%v", err)
-		}
 
 		if len(desc.RequestField.Name) != 0 {
 			raw = desc.RequestField.Accessor(raw)
 		}
+		if isHttpBody(raw) {
+			return nil
+		}
+
+		if err := req.ParseMultipartForm(multipartMaxMemoryFromContext(req.Context())); err != nil {
+			return decodeError(err)
+		}
 
 		var filters [][]string
 		if len(desc.RequestField.Name) != 0 {
@@ -40,14 +57,42 @@ func (m *MultipartCodec) Decoder(req *http.Request) func(any) error {
 			filters = append(filters, strings.Split(pathParameter, "."))
 		}
 
-		values := req.MultipartForm.Value
-		for key, files := range req.MultipartForm.File {
-			values[key] = make([]string, len(files))
-			for i, file := range files {
-				values[key][i] = strconv.FormatUint(uint64(uintptr(unsafe.Pointer(file))), 10)
+		values := maps.Clone(req.MultipartForm.Value)
+		if len(req.MultipartForm.File) != 0 {
+			uploads, found := multipart.UploadsFromContext(req.Context())
+			if !found {
+				return status.Errorf(codes.Internal, "%v", multipart.ErrNoUploads)
 			}
+
+			ids := make(map[string][]string)
+			for key, files := range req.MultipartForm.File {
+				// the "<field>.ptr" keys of the earlier versions are still accepted
+				field := strings.TrimSuffix(key, ".ptr")
+				for _, file := range files {
+					ids[field+".ids"] = append(ids[field+".ids"], uploads.Add(file))
+				}
+			}
+			maps.Copy(values, ids)
 		}
 
 		return runtime.PopulateQueryParameters(raw.(proto.Message), values, utilities.NewDoubleArray(filters))
 	}
 }
+
+// multipartMaxMemoryKey is how we find the multipart memory threshold in a context.Context.
+type multipartMaxMemoryKey struct{}
+
+// newContextWithMultipartMaxMemory returns a new Context, derived from ctx, which carries
+// the number of bytes of a multipart form kept in memory.
+func newContextWithMultipartMaxMemory(ctx context.Context, maxMemory int64) context.Context {
+	return context.WithValue(ctx, multipartMaxMemoryKey{}, maxMemory)
+}
+
+// multipartMaxMemoryFromContext returns the multipart memory threshold from ctx,
+// or DefaultMultipartMaxMemory if there is none.
+func multipartMaxMemoryFromContext(ctx context.Context) int64 {
+	if raw := ctx.Value(multipartMaxMemoryKey{}); raw != nil {
+		return raw.(int64)
+	}
+	return DefaultMultipartMaxMemory
+}
@@ -0,0 +1,140 @@
+package alchemy_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wjiec/alchemy"
+	"github.com/wjiec/alchemy/internal/testpb"
+	alchemymultipart "github.com/wjiec/alchemy/multipart"
+)
+
+func TestMultipartCodec_Decoder(t *testing.T) {
+	var factory alchemy.MultipartCodec
+
+	t.Run("form values", func(t *testing.T) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		require.NoError(t, mw.WriteField("string_value", "foo"))
+		require.NoError(t, mw.WriteField("int64_value", "42"))
+		require.NoError(t, mw.Close())
+
+		req := NewRequest(
+			WithBody(&body),
+			WithHeader(http.Header{"Content-Type": []string{mw.FormDataContentType()}}),
+		)
+
+		if dec := factory.Decoder(req); assert.NotNil(t, dec) {
+			var message testpb.Proto3Message
+			if err := dec(&message); assert.NoError(t, err) {
+				assert.Equal(t, "foo", message.StringValue)
+				assert.Equal(t, int64(42), message.Int64Value)
+			}
+		}
+	})
+
+	t.Run("form files", func(t *testing.T) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, err := mw.CreateFormFile("multipart_value", "hello.txt")
+		require.NoError(t, err)
+		_, err = fw.Write([]byte("hello world"))
+		require.NoError(t, err)
+		require.NoError(t, mw.Close())
+
+		req := NewRequest(
+			WithBody(&body),
+			WithHeader(http.Header{"Content-Type": []string{mw.FormDataContentType()}}),
+		)
+		ctx := alchemymultipart.NewContextWithUploads(req.Context(), alchemymultipart.NewUploads())
+		req = req.WithContext(ctx)
+
+		if dec := factory.Decoder(req); assert.NotNil(t, dec) {
+			var message testpb.Proto3Message
+			if err := dec(&message); assert.NoError(t, err) {
+				files, err := alchemymultipart.GetUploadsFromMultipartContext(ctx, message.MultipartValue)
+				if assert.NoError(t, err) && assert.Len(t, files, 1) {
+					assert.Equal(t, "hello.txt", files[0].Filename)
+
+					fp, err := files[0].Open()
+					require.NoError(t, err)
+					defer func() { _ = fp.Close() }()
+
+					content, _ := io.ReadAll(fp)
+					assert.Equal(t, "hello world", string(content))
+				}
+				// the handlers of the earlier versions resolve the files without the context
+				assert.Equal(t, files, alchemymultipart.GetUploadsFromMultipart(message.MultipartValue))
+			}
+		}
+	})
+
+	t.Run("legacy form key", func(t *testing.T) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, err := mw.CreateFormFile("multipart_value.ptr", "hello.txt")
+		require.NoError(t, err)
+		_, err = fw.Write([]byte("hello world"))
+		require.NoError(t, err)
+		require.NoError(t, mw.Close())
+
+		req := NewRequest(
+			WithBody(&body),
+			WithHeader(http.Header{"Content-Type": []string{mw.FormDataContentType()}}),
+		)
+		ctx := alchemymultipart.NewContextWithUploads(req.Context(), alchemymultipart.NewUploads())
+		req = req.WithContext(ctx)
+
+		if dec := factory.Decoder(req); assert.NotNil(t, dec) {
+			var message testpb.Proto3Message
+			if err := dec(&message); assert.NoError(t, err) {
+				files, err := alchemymultipart.GetUploadsFromMultipartContext(ctx, message.MultipartValue)
+				if assert.NoError(t, err) && assert.Len(t, files, 1) {
+					assert.Equal(t, "hello.txt", files[0].Filename)
+				}
+			}
+		}
+	})
+
+	t.Run("forged file", func(t *testing.T) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		require.NoError(t, mw.WriteField("multipart_value.ids", "824634330112"))
+		require.NoError(t, mw.Close())
+
+		req := NewRequest(
+			WithBody(&body),
+			WithHeader(http.Header{"Content-Type": []string{mw.FormDataContentType()}}),
+		)
+		ctx := alchemymultipart.NewContextWithUploads(context.Background(), alchemymultipart.NewUploads())
+
+		if dec := factory.Decoder(req); assert.NotNil(t, dec) {
+			var message testpb.Proto3Message
+			if err := dec(&message); assert.NoError(t, err) {
+				_, err = alchemymultipart.GetUploadsFromMultipartContext(ctx, message.MultipartValue)
+				assert.ErrorIs(t, err, alchemymultipart.ErrUnknownUpload)
+			}
+		}
+	})
+
+	t.Run("not multipart", func(t *testing.T) {
+		req := NewRequest(
+			WithBody(bytes.NewReader([]byte(`{"string_value": "foo"}`))),
+			WithHeader(http.Header{"Content-Type": []string{"application/json"}}),
+		)
+
+		if dec := factory.Decoder(req); assert.NotNil(t, dec) {
+			var message testpb.Proto3Message
+			if err := dec(&message); assert.NoError(t, err) {
+				assert.Empty(t, message.StringValue)
+			}
+		}
+	})
+}
@@ -2,12 +2,15 @@ package alchemy
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
+
+	"github.com/wjiec/alchemy/internal/pattern"
 )
 
 // PathDecoder implements request path parameter decoding for HTTP requests.
@@ -26,6 +29,10 @@ func (p *PathDecoder) Decoder(req *http.Request) func(any) error {
 		}
 
 		for k, v := range mux.Vars(req) {
+			if strings.HasPrefix(k, pattern.WildcardVarPrefix) {
+				continue
+			}
+
 			delete(requiredNames, k)
 			if err := runtime.PopulateFieldFromPath(msg.(proto.Message), k, v); err != nil {
 				return status.Errorf(codes.InvalidArgument, "type mismatch, path parameter: %s, error: %v", k, err)
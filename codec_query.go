@@ -6,8 +6,6 @@ import (
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -20,7 +18,7 @@ func (q *QueryDecoder) Decoder(req *http.Request) func(any) error {
 	desc, _ := RouteDescFromContext(req.Context())
 	return func(raw any) error {
 		if err := req.ParseForm(); err != nil {
-			return status.Errorf(codes.InvalidArgument, "%v", err)
+			return decodeError(err)
 		}
 
 		var filters [][]string
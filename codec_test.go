@@ -19,12 +19,12 @@ type Empty struct{}
 func (Empty) Read([]byte) (int, error) { return 0, io.EOF }
 
 func NewRequest(options ...RequestOption) *http.Request {
-	builder := &RequestBuilder{RouteDesc: &alchemy.RouteDesc{}, Body: Empty{}}
+	builder := &RequestBuilder{Method: http.MethodGet, RouteDesc: &alchemy.RouteDesc{}, Body: Empty{}}
 	for _, applyOption := range options {
 		applyOption(builder)
 	}
 
-	req, err := http.NewRequest(http.MethodGet, "http://localhost/api?"+builder.Query.Encode(), builder.Body)
+	req, err := http.NewRequest(builder.Method, "http://localhost/api?"+builder.Query.Encode(), builder.Body)
 	if err != nil {
 		panic(err)
 	}
@@ -37,6 +37,7 @@ func NewRequest(options ...RequestOption) *http.Request {
 }
 
 type RequestBuilder struct {
+	Method    string
 	Query     url.Values
 	Body      io.Reader
 	Header    http.Header
@@ -46,6 +47,12 @@ type RequestBuilder struct {
 
 type RequestOption func(builder *RequestBuilder)
 
+func WithMethod(method string) RequestOption {
+	return func(b *RequestBuilder) {
+		b.Method = method
+	}
+}
+
 func WithRouteDesc(desc *alchemy.RouteDesc) RequestOption {
 	return func(b *RequestBuilder) {
 		b.RouteDesc = desc
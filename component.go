@@ -0,0 +1,204 @@
+package alchemy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultComponentStartTimeout = 15 * time.Second
+	DefaultComponentStopTimeout  = 15 * time.Second
+)
+
+// Component represents a part of the application with its own lifecycle, e.g.
+// a message consumer or a cache which must be ready before the traffic arrives.
+type Component interface {
+	// Start starts the component, the App aborts the startup if it fails.
+	//
+	// The context is done when the start timeout is exceeded, it should not
+	// be retained by the component after Start returns. If Start does not
+	// return in time, the component may be partially started and is stopped
+	// as well, even though Start may still be running.
+	Start(ctx context.Context) error
+
+	// Stop stops the component after the servers are stopped.
+	Stop(ctx context.Context) error
+}
+
+// NewComponent returns a Component which calls the start and stop functions,
+// either of which may be nil.
+func NewComponent(start, stop func(ctx context.Context) error) Component {
+	return &funcComponent{start: start, stop: stop}
+}
+
+// funcComponent is a Component implemented by functions.
+type funcComponent struct {
+	start, stop func(ctx context.Context) error
+}
+
+// Start calls the start function if any.
+func (c *funcComponent) Start(ctx context.Context) error {
+	if c.start == nil {
+		return nil
+	}
+	return c.start(ctx)
+}
+
+// Stop calls the stop function if any.
+func (c *funcComponent) Stop(ctx context.Context) error {
+	if c.stop == nil {
+		return nil
+	}
+	return c.stop(ctx)
+}
+
+// component represents a named Component registered to the App.
+type component struct {
+	name      string
+	impl      Component
+	dependsOn []string
+}
+
+// reservedComponentPrefix is the prefix of the names of the components registered
+// by the App itself, which cannot be used by the named components.
+const reservedComponentPrefix = "alchemy:"
+
+// WithComponent registers a named Component to the App.
+//
+// The components are started before the servers begin listening, each component
+// is started after the components it depends on, and stopped in reverse order
+// after the servers are stopped. The names starting with "alchemy:" are reserved.
+func WithComponent(name string, c Component, dependsOn ...string) AppOption {
+	return func(app *App) error {
+		if strings.HasPrefix(name, reservedComponentPrefix) {
+			return fmt.Errorf("alchemy: component name %q uses the reserved prefix %q", name, reservedComponentPrefix)
+		}
+		app.components = append(app.components, &component{name: name, impl: c, dependsOn: dependsOn})
+		return nil
+	}
+}
+
+// WithOnStart registers a hook run with the components before the servers begin listening.
+//
+// It is a shorthand for an anonymous Component without Stop.
+func WithOnStart(hook func(ctx context.Context) error) AppOption {
+	return func(app *App) error {
+		name := fmt.Sprintf("%son-start-%d", reservedComponentPrefix, len(app.components))
+		app.components = append(app.components, &component{name: name, impl: NewComponent(hook, nil)})
+		return nil
+	}
+}
+
+// WithComponentTimeouts configures the timeouts of starting and stopping each component.
+func WithComponentTimeouts(start, stop time.Duration) AppOption {
+	return func(app *App) error {
+		app.componentStartTimeout = start
+		app.componentStopTimeout = stop
+		return nil
+	}
+}
+
+// sortComponents sorts the components so that each component follows the
+// components it depends on, the order of registration is kept otherwise.
+func sortComponents(components []*component) ([]*component, error) {
+	byName := make(map[string]*component, len(components))
+	for _, c := range components {
+		if _, found := byName[c.name]; found {
+			return nil, fmt.Errorf("alchemy: duplicate component %q", c.name)
+		}
+		byName[c.name] = c
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+	states := make(map[string]int, len(components))
+	sorted := make([]*component, 0, len(components))
+
+	var visit func(c *component, path []string) error
+	visit = func(c *component, path []string) error {
+		switch states[c.name] {
+		case visiting:
+			return fmt.Errorf("alchemy: component dependency cycle %q", append(path, c.name))
+		case visited:
+			return nil
+		}
+
+		states[c.name] = visiting
+		for _, name := range c.dependsOn {
+			dependency, found := byName[name]
+			if !found {
+				return fmt.Errorf("alchemy: component %q depends on unknown component %q", c.name, name)
+			}
+			if err := visit(dependency, append(path, c.name)); err != nil {
+				return err
+			}
+		}
+		states[c.name] = visited
+		sorted = append(sorted, c)
+		return nil
+	}
+
+	for _, c := range components {
+		if err := visit(c, nil); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// startComponents starts the components in order, the started components are
+// stopped if any of the components fails to start.
+//
+// A component which does not start in time is stopped as well, since it may
+// have acquired some resources before its context is done.
+func (a *App) startComponents(ctx context.Context) error {
+	for _, c := range a.components {
+		err := runWithTimeout(ctx, a.componentStartTimeout, c.impl.Start)
+		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+				a.startedComponents = append(a.startedComponents, c)
+			}
+			err = fmt.Errorf("alchemy: start component %q: %w", c.name, err)
+			return errors.Join(err, a.stopComponents())
+		}
+		a.startedComponents = append(a.startedComponents, c)
+	}
+	return nil
+}
+
+// stopComponents stops the started components in reverse order, all the
+// components are stopped even if some of them fail.
+func (a *App) stopComponents() error {
+	var errs []error
+	for _, c := range slices.Backward(a.startedComponents) {
+		if err := runWithTimeout(context.Background(), a.componentStopTimeout, c.impl.Stop); err != nil {
+			errs = append(errs, fmt.Errorf("alchemy: stop component %q: %w", c.name, err))
+		}
+	}
+	a.startedComponents = nil
+
+	return errors.Join(errs...)
+}
+
+// runWithTimeout calls fn with a context with the timeout, it returns the error
+// of the context if fn does not return in time.
+func runWithTimeout(ctx context.Context, timeout time.Duration, fn func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- fn(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,153 @@
+package alchemy_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wjiec/alchemy"
+)
+
+// RecordComponent returns a Component that records its lifecycle into events.
+func RecordComponent(name string, events *[]string, startErr error) alchemy.Component {
+	return alchemy.NewComponent(func(ctx context.Context) error {
+		*events = append(*events, "start "+name)
+		return startErr
+	}, func(ctx context.Context) error {
+		*events = append(*events, "stop "+name)
+		return nil
+	})
+}
+
+func TestWithComponent(t *testing.T) {
+	var app *alchemy.App
+	var events []string
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0")),
+		alchemy.WithComponent("consumer", RecordComponent("consumer", &events, nil), "cache", "database"),
+		alchemy.WithComponent("cache", RecordComponent("cache", &events, nil), "database"),
+		alchemy.WithComponent("database", RecordComponent("database", &events, nil)),
+		alchemy.WithOnStart(func(ctx context.Context) error {
+			// The App is not ready until all the components are started.
+			select {
+			case <-app.Ready():
+				return errors.New("app is ready")
+			default:
+			}
+			events = append(events, "on start")
+			return nil
+		}),
+		alchemy.WithOnShutdown(func(ctx context.Context) error {
+			events = append(events, "on shutdown")
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Start(ctx) }()
+	require.NoError(t, app.WaitReady(ctx))
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{
+		"start database", "start cache", "start consumer", "on start",
+		"stop consumer", "stop cache", "stop database", "on shutdown",
+	}, events)
+}
+
+func TestWithComponent_StartFailure(t *testing.T) {
+	var events []string
+	errConnect := errors.New("connection refused")
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0")),
+		alchemy.WithComponent("database", RecordComponent("database", &events, nil)),
+		alchemy.WithComponent("consumer", RecordComponent("consumer", &events, errConnect), "database"),
+		alchemy.WithComponent("cache", RecordComponent("cache", &events, nil), "consumer"),
+	)
+	require.NoError(t, err)
+
+	err = app.Start(context.Background())
+	assert.ErrorIs(t, err, errConnect)
+	assert.ErrorContains(t, err, `start component "consumer"`)
+	assert.Equal(t, []string{"start database", "start consumer", "stop database"}, events)
+}
+
+func TestWithComponentTimeouts(t *testing.T) {
+	var events []string
+	release := make(chan struct{})
+	defer close(release)
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0")),
+		alchemy.WithComponent("database", RecordComponent("database", &events, nil)),
+		alchemy.WithComponent("slow", alchemy.NewComponent(func(ctx context.Context) error {
+			<-release // ignores the context
+			return nil
+		}, func(ctx context.Context) error {
+			events = append(events, "stop slow")
+			return nil
+		}), "database"),
+		alchemy.WithComponentTimeouts(50*time.Millisecond, 50*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	start := time.Now()
+	assert.ErrorIs(t, app.Start(context.Background()), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	// The component which does not start in time may be partially started.
+	assert.Equal(t, []string{"start database", "stop slow", "stop database"}, events)
+}
+
+func TestWithComponent_InvalidDependencies(t *testing.T) {
+	component := alchemy.NewComponent(nil, nil)
+	cases := []struct {
+		Name    string
+		Options []alchemy.AppOption
+		Error   string
+	}{
+		{
+			Name: "duplicate",
+			Options: []alchemy.AppOption{
+				alchemy.WithComponent("cache", component),
+				alchemy.WithComponent("cache", component),
+			},
+			Error: `duplicate component "cache"`,
+		},
+		{
+			Name: "unknown",
+			Options: []alchemy.AppOption{
+				alchemy.WithComponent("cache", component, "database"),
+			},
+			Error: `component "cache" depends on unknown component "database"`,
+		},
+		{
+			Name: "cycle",
+			Options: []alchemy.AppOption{
+				alchemy.WithComponent("a", component, "b"),
+				alchemy.WithComponent("b", component, "c"),
+				alchemy.WithComponent("c", component, "a"),
+			},
+			Error: `component dependency cycle ["a" "b" "c" "a"]`,
+		},
+		{
+			Name: "reserved name",
+			Options: []alchemy.AppOption{
+				alchemy.WithOnStart(func(ctx context.Context) error { return nil }),
+				alchemy.WithComponent("alchemy:on-start-0", component),
+			},
+			Error: `component name "alchemy:on-start-0" uses the reserved prefix "alchemy:"`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			_, err := alchemy.New(t.Name(), tt.Options...)
+			assert.ErrorContains(t, err, tt.Error)
+		})
+	}
+}
@@ -0,0 +1,315 @@
+package alchemy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"buf.build/go/protovalidate"
+	"github.com/BurntSushi/toml"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFlag is the name of the flag specifying the configuration file.
+const ConfigFlag = "config"
+
+// WithConfig enables loading the configuration from a file and the environment
+// variables before the command runs.
+//
+// The configuration file is specified by the "--config" flag added to the root
+// command, and decoded by its extension, which is one of ".yaml", ".yml", ".json"
+// and ".toml". The values of the file and the environment variables are bound to
+// the flags that are not set on the command line, the flags take precedence over
+// the environment variables, which take precedence over the file.
+//
+// The keys of nested objects in the file are joined with "-" to match the flags,
+// and "_" is treated as "-", e.g. both "http: {addr: ...}" and "http_addr: ..."
+// set the flag "--http-addr". The environment variables are named after the
+// flags with a prefix derived from the App's name, e.g. "MY_APP_HTTP_ADDR" sets
+// the flag "--http-addr" for the App named "my-app".
+//
+// The configuration is loaded by the PersistentPreRunE of the root command, the
+// PersistentPreRunE or PersistentPreRun of the subcommands, which cobra runs
+// instead of the root's, are wrapped before the App starts to load it first.
+func WithConfig(options ...ConfigOption) AppOption {
+	return func(app *App) error {
+		loader := &configLoader{envPrefix: envName(app.name) + "_"}
+		for _, applyConfigOption := range options {
+			if err := applyConfigOption(loader); err != nil {
+				return err
+			}
+		}
+
+		app.root.PersistentFlags().StringVar(&loader.file, ConfigFlag, loader.file, "The path of the configuration file")
+		loader.wrapPreRun(app.root)
+		app.beforeStart = append(app.beforeStart, func(ctx context.Context, root *cobra.Command) error {
+			// The hooks of all the parents are run if cobra traverses the hooks.
+			if !cobra.EnableTraverseRunHooks {
+				loader.wrapSubCommandPreRuns(root)
+			}
+			return nil
+		})
+		if loader.reload {
+			name := reservedComponentPrefix + "config-reload"
+			app.components = append(app.components, &component{name: name, impl: NewComponent(loader.startWatching, loader.stopWatching)})
+			app.workers = append(app.workers, &worker{name: name, run: loader.watch})
+		}
+		return nil
+	}
+}
+
+// ConfigOption used to configure the loading of the configuration.
+type ConfigOption func(loader *configLoader) error
+
+// ConfigWithFile configures the default configuration file used if the "--config"
+// flag is not set, it is ignored if the file does not exist.
+func ConfigWithFile(path string) ConfigOption {
+	return func(loader *configLoader) error {
+		loader.file = path
+		return nil
+	}
+}
+
+// ConfigWithEnvPrefix configures the prefix of the environment variables instead
+// of the one derived from the App's name, an empty prefix disables the environment
+// variables.
+func ConfigWithEnvPrefix(prefix string) ConfigOption {
+	return func(loader *configLoader) error {
+		loader.envPrefix = prefix
+		return nil
+	}
+}
+
+// ConfigWithDecode decodes the configuration file into target, which is a pointer
+// to a struct decoded with the json tags of its fields, or a proto message decoded
+// with protojson and validated with protovalidate.
+//
+// The unknown fields of the file are ignored, since the file also contains the
+// values of the flags.
+func ConfigWithDecode(target any) ConfigOption {
+	return func(loader *configLoader) error {
+		loader.targets = append(loader.targets, target)
+		return nil
+	}
+}
+
+// configLoader loads the configuration and binds it to the flags.
+type configLoader struct {
+	file        string
+	envPrefix   string
+	targets     []any
+	reload      bool
+	subscribers []configSubscriber
+	wrapped     map[*cobra.Command]bool
+
+	hup     chan os.Signal
+	watcher *fsnotify.Watcher
+}
+
+// wrapPreRun wraps the PersistentPreRunE or PersistentPreRun of the command to
+// load the configuration before calling it.
+func (l *configLoader) wrapPreRun(cmd *cobra.Command) {
+	if l.wrapped == nil {
+		l.wrapped = make(map[*cobra.Command]bool)
+	}
+	if l.wrapped[cmd] {
+		return
+	}
+	l.wrapped[cmd] = true
+
+	preRunE, preRun := cmd.PersistentPreRunE, cmd.PersistentPreRun
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := l.load(cmd.Context(), cmd.Flags(), cmd.Flags().Changed(ConfigFlag)); err != nil {
+			return err
+		}
+		switch {
+		case preRunE != nil:
+			return preRunE(cmd, args)
+		case preRun != nil:
+			preRun(cmd, args)
+		}
+		return nil
+	}
+}
+
+// wrapSubCommandPreRuns wraps the persistent pre-run hooks of the subcommands of
+// cmd, which replace the hook of the root command.
+func (l *configLoader) wrapSubCommandPreRuns(cmd *cobra.Command) {
+	for _, sub := range cmd.Commands() {
+		if sub.PersistentPreRunE != nil || sub.PersistentPreRun != nil {
+			l.wrapPreRun(sub)
+		}
+		l.wrapSubCommandPreRuns(sub)
+	}
+}
+
+// load loads the configuration file and the environment variables, then binds
+// them to the flags, decodes the file into the targets and notifies the subscribers.
+//
+// The missing configuration file is ignored unless it is explicitly specified.
+func (l *configLoader) load(ctx context.Context, flags *pflag.FlagSet, explicit bool) error {
+	values := make(map[string]any)
+	if len(l.file) != 0 {
+		var err error
+		if values, err = readConfigFile(l.file); err != nil {
+			if explicit || !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			values = make(map[string]any)
+		}
+	}
+
+	flattened := make(map[string]any)
+	flattenConfig(flattened, "", values)
+
+	var errs []error
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed || flag.Name == ConfigFlag {
+			return
+		}
+
+		if len(l.envPrefix) != 0 {
+			if value, found := os.LookupEnv(l.envPrefix + envName(flag.Name)); found {
+				if err := flags.Set(flag.Name, value); err != nil {
+					errs = append(errs, fmt.Errorf("alchemy: environment variable %s: %w", l.envPrefix+envName(flag.Name), err))
+				}
+				return
+			}
+		}
+		if value, found := flattened[configKey(flag.Name)]; found {
+			if err := setFlagValue(flag, value); err != nil {
+				errs = append(errs, fmt.Errorf("alchemy: config %q: %w", flag.Name, err))
+			}
+		}
+	})
+	if len(errs) != 0 {
+		return errors.Join(errs...)
+	}
+
+	for _, target := range l.targets {
+		if err := decodeConfig(values, target); err != nil {
+			return fmt.Errorf("alchemy: decode config file %s: %w", l.file, err)
+		}
+	}
+	return l.notify(ctx, values)
+}
+
+// readConfigFile reads and decodes the configuration file by its extension.
+func readConfigFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".json":
+		err = json.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("alchemy: unsupported config file format %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("alchemy: parse config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// flattenConfig flattens the nested objects of the values into dst with the
+// keys joined by "-".
+func flattenConfig(dst map[string]any, prefix string, values map[string]any) {
+	for key, value := range values {
+		key = configKey(key)
+		if len(prefix) != 0 {
+			key = prefix + "-" + key
+		}
+
+		if nested, ok := value.(map[string]any); ok {
+			flattenConfig(dst, key, nested)
+		} else {
+			dst[key] = value
+		}
+	}
+}
+
+// setFlagValue sets the value from the configuration file to the flag.
+func setFlagValue(flag *pflag.Flag, value any) error {
+	if items, ok := value.([]any); ok {
+		values := make([]string, len(items))
+		for i, item := range items {
+			values[i] = configValueString(item)
+		}
+
+		if slice, ok := flag.Value.(pflag.SliceValue); ok {
+			if err := slice.Replace(values); err != nil {
+				return err
+			}
+			flag.Changed = true
+			return nil
+		}
+		value = strings.Join(values, ",")
+	}
+
+	if err := flag.Value.Set(configValueString(value)); err != nil {
+		return err
+	}
+	flag.Changed = true
+	return nil
+}
+
+// configValueString formats the value from the configuration file as the value
+// of a flag, the numbers decoded as float64 are formatted without exponent, so
+// that the large integers are accepted by the integer flags.
+func configValueString(value any) string {
+	if number, ok := value.(float64); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// decodeConfig decodes the values into target.
+func decodeConfig(values map[string]any, target any) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	if msg, ok := target.(proto.Message); ok {
+		if err = (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, msg); err != nil {
+			return err
+		}
+		return protovalidate.Validate(msg)
+	}
+	return json.Unmarshal(data, target)
+}
+
+// configKey normalizes the key of the configuration.
+func configKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+// envName converts the name to the name of an environment variable, e.g. "http-addr"
+// is converted to "HTTP_ADDR".
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return '_'
+		}
+		return unicode.ToUpper(r)
+	}, name)
+}
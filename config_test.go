@@ -0,0 +1,177 @@
+package alchemy_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wjiec/alchemy"
+	"github.com/wjiec/alchemy/internal/testpb"
+)
+
+// RunConfigCommand runs a subcommand with args in an App configured with options,
+// and returns the values of the flags seen by the subcommand.
+func RunConfigCommand(t *testing.T, args []string, options ...alchemy.AppOption) (map[string]string, error) {
+	values := make(map[string]string)
+	command := &cobra.Command{
+		Use: "print",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, name := range []string{"http-addr", "grpc-addr", "tags", "max-conns"} {
+				if flag := cmd.Flags().Lookup(name); flag != nil {
+					values[name] = flag.Value.String()
+				}
+			}
+			return nil
+		},
+	}
+	command.Flags().StringSlice("tags", nil, "")
+	command.Flags().Int("max-conns", 0, "")
+
+	options = append(options,
+		alchemy.WithHttpServer(alchemy.FlagTCP("http-addr", ":8080")),
+		alchemy.WithGrpcServer(alchemy.FlagTCP("grpc-addr", ":8081")),
+		alchemy.WithSubCommand(command),
+		alchemy.WithBeforeStart(func(ctx context.Context, root *cobra.Command) error {
+			root.SetArgs(append([]string{"print"}, args...))
+			root.SilenceUsage = true
+			return nil
+		}),
+	)
+	app, err := alchemy.New("my-app", options...)
+	require.NoError(t, err)
+
+	return values, app.Start(context.Background())
+}
+
+// WriteConfigFile writes the content to the file of the given name in a temporary directory.
+func WriteConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestWithConfig(t *testing.T) {
+	cases := []struct {
+		Name string
+		File string
+		Data string
+	}{
+		{Name: "yaml", File: "config.yaml", Data: "http:\n  addr: ':9090'\ngrpc_addr: ':9091'\ntags: [a, b]\n"},
+		{Name: "json", File: "config.json", Data: `{"http": {"addr": ":9090"}, "grpc-addr": ":9091", "tags": ["a", "b"]}`},
+		{Name: "toml", File: "config.toml", Data: "grpc_addr = ':9091'\ntags = ['a', 'b']\n[http]\naddr = ':9090'\n"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			path := WriteConfigFile(t, tt.File, tt.Data)
+
+			values, err := RunConfigCommand(t, []string{"--config", path}, alchemy.WithConfig())
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"http-addr": ":9090", "grpc-addr": ":9091", "tags": "[a,b]", "max-conns": "0"}, values)
+		})
+	}
+}
+
+func TestWithConfig_Numbers(t *testing.T) {
+	path := WriteConfigFile(t, "config.json", `{"max_conns": 1000000, "tags": [2500000, 0.5, "a"]}`)
+
+	values, err := RunConfigCommand(t, []string{"--config", path}, alchemy.WithConfig())
+	require.NoError(t, err)
+	assert.Equal(t, "1000000", values["max-conns"])
+	assert.Equal(t, "[2500000,0.5,a]", values["tags"])
+}
+
+func TestWithConfig_SubCommandPreRun(t *testing.T) {
+	path := WriteConfigFile(t, "config.yaml", "http-addr: ':9090'\n")
+
+	var preRunAddr, runAddr string
+	command := &cobra.Command{
+		Use: "print",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			preRunAddr = cmd.Flags().Lookup("http-addr").Value.String()
+			return nil
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			runAddr = cmd.Flags().Lookup("http-addr").Value.String()
+		},
+	}
+
+	app, err := alchemy.New("my-app",
+		alchemy.WithHttpServer(alchemy.FlagTCP("http-addr", ":8080")),
+		alchemy.WithConfig(alchemy.ConfigWithFile(path)),
+		alchemy.WithSubCommand(command),
+		alchemy.WithBeforeStart(func(ctx context.Context, root *cobra.Command) error {
+			root.SetArgs([]string{"print"})
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, app.Start(context.Background()))
+	assert.Equal(t, ":9090", preRunAddr)
+	assert.Equal(t, ":9090", runAddr)
+}
+
+func TestWithConfig_Precedence(t *testing.T) {
+	path := WriteConfigFile(t, "config.yaml", "http-addr: ':9090'\ngrpc-addr: ':9091'\n")
+	t.Setenv("MY_APP_HTTP_ADDR", ":7070")
+	t.Setenv("MY_APP_GRPC_ADDR", ":7071")
+
+	values, err := RunConfigCommand(t, []string{"--grpc-addr", ":6061"}, alchemy.WithConfig(alchemy.ConfigWithFile(path)))
+	require.NoError(t, err)
+	assert.Equal(t, ":7070", values["http-addr"])
+	assert.Equal(t, ":6061", values["grpc-addr"])
+
+	values, err = RunConfigCommand(t, nil, alchemy.WithConfig(alchemy.ConfigWithFile(path), alchemy.ConfigWithEnvPrefix("")))
+	require.NoError(t, err)
+	assert.Equal(t, ":9090", values["http-addr"])
+	assert.Equal(t, ":9091", values["grpc-addr"])
+}
+
+func TestWithConfig_MissingFile(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "config.yaml")
+
+	values, err := RunConfigCommand(t, nil, alchemy.WithConfig(alchemy.ConfigWithFile(missing)))
+	require.NoError(t, err)
+	assert.Equal(t, ":8080", values["http-addr"])
+
+	_, err = RunConfigCommand(t, []string{"--config", missing}, alchemy.WithConfig())
+	assert.Error(t, err)
+
+	_, err = RunConfigCommand(t, []string{"--config", WriteConfigFile(t, "config.ini", "")}, alchemy.WithConfig())
+	assert.ErrorContains(t, err, `unsupported config file format ".ini"`)
+}
+
+func TestConfigWithDecode(t *testing.T) {
+	path := WriteConfigFile(t, "config.yaml", `
+http_addr: ':9090'
+database:
+  url: postgres://localhost/library
+  max_conns: 16
+`)
+
+	var config struct {
+		Database struct {
+			URL      string `json:"url"`
+			MaxConns int    `json:"max_conns"`
+		} `json:"database"`
+	}
+	values, err := RunConfigCommand(t, []string{"--config", path}, alchemy.WithConfig(alchemy.ConfigWithDecode(&config)))
+	require.NoError(t, err)
+	assert.Equal(t, ":9090", values["http-addr"])
+	assert.Equal(t, "postgres://localhost/library", config.Database.URL)
+	assert.Equal(t, 16, config.Database.MaxConns)
+
+	path = WriteConfigFile(t, "message.json", `{"string_value": "foobar", "int64Value": "64", "repeated_string": ["a"], "http_addr": ":9090"}`)
+	var message testpb.Proto3Message
+	_, err = RunConfigCommand(t, []string{"--config", path}, alchemy.WithConfig(alchemy.ConfigWithDecode(&message)))
+	require.NoError(t, err)
+	assert.Equal(t, "foobar", message.GetStringValue())
+	assert.Equal(t, int64(64), message.GetInt64Value())
+	assert.Equal(t, []string{"a"}, message.GetRepeatedString())
+}
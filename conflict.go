@@ -0,0 +1,270 @@
+package alchemy
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"github.com/wjiec/alchemy/internal/pattern"
+)
+
+// ErrRouteConflict is returned when a registered HTTP route can never be
+// reached because it is shadowed by a route registered before it.
+var ErrRouteConflict = errors.New("alchemy: route conflict")
+
+// routeConflict describes an HTTP route shadowed by an earlier route.
+type routeConflict struct {
+	route    *routeEntry
+	shadowed *routeEntry
+}
+
+// Error implements the error interface.
+func (c *routeConflict) Error() string {
+	return fmt.Sprintf("%s is shadowed by %s", c.shadowed, c.route)
+}
+
+// Unwrap returns ErrRouteConflict, so that the conflict can be matched with errors.Is.
+func (c *routeConflict) Unwrap() error {
+	return ErrRouteConflict
+}
+
+// routeEntry represents a route in the order in which the router matches them.
+type routeEntry struct {
+	method   string
+	pattern  string
+	rpc      string // the full name of the gRPC method, if known
+	template *pattern.Template
+
+	additional bool // whether the route is an additional handler
+}
+
+// String returns the description of the route used in error messages.
+func (e *routeEntry) String() string {
+	switch {
+	case e.additional:
+		return fmt.Sprintf("additional handler %s %s", e.method, e.pattern)
+	case len(e.rpc) == 0:
+		return fmt.Sprintf("route %s %s", e.method, e.pattern)
+	}
+	return fmt.Sprintf("%s (%s %s)", e.rpc, e.method, e.pattern)
+}
+
+// routeOverlap describes an HTTP route partially shadowed by an earlier route,
+// or an additional handler shadowed by a route with another HTTP method.
+type routeOverlap struct {
+	route    *routeEntry
+	shadowed *routeEntry
+	covered  bool // whether all the requests of the shadowed route are matched by the route
+}
+
+// String returns the description of the overlap used in warnings.
+func (o *routeOverlap) String() string {
+	switch {
+	case o.route.method == o.shadowed.method:
+		return fmt.Sprintf("%s is partially shadowed by %s", o.shadowed, o.route)
+	case o.covered:
+		return fmt.Sprintf("%s is shadowed by %s, which responds to its requests "+
+			"with 405 Method Not Allowed", o.shadowed, o.route)
+	}
+	return fmt.Sprintf("%s is partially shadowed by %s, which responds to the requests "+
+		"matched by both with 405 Method Not Allowed", o.shadowed, o.route)
+}
+
+// checkRoutes analyzes the routes registered to the server and reports the routes
+// which can never be reached because an earlier route matches all their requests.
+//
+// The conflicts are logged as warnings instead of returned if the server is
+// configured with HttpWithRouteConflictsWarnOnly. The routes which are only
+// partially shadowed by an earlier route, and the additional handlers whose
+// requests are matched by a route with another HTTP method, which makes the
+// router respond with 405 Method Not Allowed, are always logged as warnings.
+func (hs *httpServer) checkRoutes() error {
+	entries, err := hs.routeEntries()
+	if err != nil {
+		return err
+	}
+
+	var conflicts []error
+	var overlaps []*routeOverlap
+	for i, shadowed := range entries {
+		for _, route := range entries[:i] {
+			sameMethod := route.method == shadowed.method
+			if !sameMethod && (route.additional || !shadowed.additional) {
+				continue
+			}
+
+			if templateCovers(route.template, shadowed.template) {
+				if sameMethod {
+					conflicts = append(conflicts, &routeConflict{route: route, shadowed: shadowed})
+					break
+				}
+				overlaps = append(overlaps, &routeOverlap{route: route, shadowed: shadowed, covered: true})
+			} else if templatesOverlap(route.template, shadowed.template) {
+				overlaps = append(overlaps, &routeOverlap{route: route, shadowed: shadowed})
+			}
+		}
+	}
+
+	for _, overlap := range overlaps {
+		slog.Warn("Detected a route overlap", "warning", overlap.String())
+	}
+	if hs.warnRouteConflicts {
+		for _, conflict := range conflicts {
+			slog.Warn("Detected a route conflict", "error", conflict)
+		}
+		return nil
+	}
+	return errors.Join(conflicts...)
+}
+
+// routeEntries returns the routes of the server in the order in which they are
+// matched by the router: the routes with custom verbs, the other routes and the
+// additional handlers.
+//
+// The additional handlers whose patterns are not valid path templates are not
+// taken into account, since they are matched by the gorilla router as-is.
+func (hs *httpServer) routeEntries() ([]*routeEntry, error) {
+	var verbs, others []*routeEntry
+	for _, route := range hs.routes {
+		template, err := pattern.ParseTemplate(route.desc.PathPattern)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := &routeEntry{
+			method:   route.desc.HttpMethod,
+			pattern:  route.desc.PathPattern,
+			rpc:      route.desc.FullMethod,
+			template: template,
+		}
+		if template.HasVerb() {
+			verbs = append(verbs, entry)
+		} else {
+			others = append(others, entry)
+		}
+	}
+
+	entries := append(verbs, others...)
+	for _, route := range hs.additionalRoutes {
+		if template, err := pattern.ParseTemplate(route.PathPattern); err == nil {
+			entries = append(entries, &routeEntry{
+				method:     route.HttpMethod,
+				pattern:    route.PathPattern,
+				template:   template,
+				additional: true,
+			})
+		}
+	}
+	return entries, nil
+}
+
+// pathAtom is a flattened segment of a path template, which matches a single
+// path segment, or any number of segments for "**".
+type pathAtom struct {
+	literal  string
+	wildcard string
+	regexp   string
+}
+
+// templateAtoms returns the flattened segments of the template.
+func templateAtoms(t *pattern.Template) []pathAtom {
+	return appendAtoms(nil, t.Segments)
+}
+
+// appendAtoms appends the flattened segments to atoms, the variables are replaced
+// by the segments they match.
+func appendAtoms(atoms []pathAtom, segments []pattern.Segment) []pathAtom {
+	for _, segment := range segments {
+		switch {
+		case len(segment.Variable) != 0 && len(segment.Regexp) != 0:
+			atoms = append(atoms, pathAtom{regexp: segment.Regexp})
+		case len(segment.Variable) != 0 && segment.Bindings == nil:
+			atoms = append(atoms, pathAtom{wildcard: "*"})
+		case len(segment.Variable) != 0:
+			atoms = appendAtoms(atoms, segment.Bindings)
+		case len(segment.Wildcard) != 0:
+			atoms = append(atoms, pathAtom{wildcard: segment.Wildcard})
+		default:
+			atoms = append(atoms, pathAtom{literal: segment.Literal})
+		}
+	}
+	return atoms
+}
+
+// templateCovers reports whether the template t matches all the paths matched by other.
+//
+// The analysis is conservative: the gorilla-style regexps are only known to
+// match the literals they match and the identical regexps.
+func templateCovers(t, other *pattern.Template) bool {
+	return t.Verb == other.Verb && atomsCover(templateAtoms(t), templateAtoms(other))
+}
+
+// templatesOverlap reports whether some paths are matched by both templates.
+//
+// The analysis is conservative: the different gorilla-style regexps are only
+// known to overlap the wildcards and the literals they match.
+func templatesOverlap(t, other *pattern.Template) bool {
+	return t.Verb == other.Verb && atomsOverlap(templateAtoms(t), templateAtoms(other))
+}
+
+// atomsOverlap reports whether some paths are matched by both the atoms in a and b.
+func atomsOverlap(a, b []pathAtom) bool {
+	switch {
+	case len(a) != 0 && a[0].wildcard == "**":
+		return atomsOverlap(a[1:], b) || (len(b) != 0 && atomsOverlap(a, b[1:]))
+	case len(b) != 0 && b[0].wildcard == "**":
+		return atomsOverlap(b, a)
+	case len(a) == 0 || len(b) == 0:
+		return len(a) == len(b)
+	}
+	return a[0].overlaps(b[0]) && atomsOverlap(a[1:], b[1:])
+}
+
+// overlaps reports whether some segments are matched by both atoms, neither of them is "**".
+func (a pathAtom) overlaps(other pathAtom) bool {
+	switch {
+	case len(a.wildcard) != 0:
+		return len(other.wildcard) != 0 || len(other.literal) != 0 || len(other.regexp) != 0
+	case len(other.wildcard) != 0:
+		return other.overlaps(a)
+	case len(a.regexp) != 0 && len(other.regexp) != 0:
+		return a.regexp == other.regexp
+	case len(a.regexp) != 0 || len(other.regexp) != 0:
+		return a.covers(other) || other.covers(a)
+	}
+	return a.literal == other.literal
+}
+
+// atomsCover reports whether the atoms in a match all the paths matched by the atoms in b.
+func atomsCover(a, b []pathAtom) bool {
+	switch {
+	case len(a) == 0:
+		return len(b) == 0
+	case a[0].wildcard == "**":
+		for i := 0; i <= len(b); i++ {
+			if atomsCover(a[1:], b[i:]) {
+				return true
+			}
+		}
+		return false
+	case len(b) == 0 || b[0].wildcard == "**":
+		return false
+	}
+	return a[0].covers(b[0]) && atomsCover(a[1:], b[1:])
+}
+
+// covers reports whether the atom matches all the segments matched by other,
+// neither of them is "**".
+func (a pathAtom) covers(other pathAtom) bool {
+	switch {
+	case a.wildcard == "*":
+		return len(other.wildcard) != 0 || len(other.literal) != 0
+	case len(a.regexp) != 0 && len(other.regexp) != 0:
+		return a.regexp == other.regexp
+	case len(a.regexp) != 0 && len(other.wildcard) == 0:
+		matched, err := regexp.MatchString("^(?:"+a.regexp+")$", other.literal)
+		return err == nil && matched
+	}
+	return len(a.wildcard) == 0 && len(other.wildcard) == 0 && len(other.regexp) == 0 && a.literal == other.literal
+}
@@ -0,0 +1,236 @@
+package alchemy_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wjiec/alchemy"
+)
+
+// StartWithRoutes starts an app serving the routes over HTTP and returns the error
+// which stops it, the app is stopped immediately if it starts successfully.
+func StartWithRoutes(t *testing.T, routes []alchemy.RouteDesc, options ...alchemy.HttpOption) error {
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0"), options...),
+		alchemy.WithServiceRegister(func(s alchemy.ServiceRegistrar, srv any) {
+			s.RegisterService(&alchemy.ServiceDesc{Routes: routes}, srv)
+		}, any(nil)),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	return app.Start(ctx)
+}
+
+func TestApp_RouteConflicts(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Routes   []alchemy.RouteDesc
+		Options  []alchemy.HttpOption
+		Conflict string
+	}{
+		{
+			Name: "same pattern",
+			Routes: []alchemy.RouteDesc{
+				{FullMethod: "/v1.Books/GetBook", HttpMethod: http.MethodGet, PathPattern: "/v1/{name=books/*}"},
+				{FullMethod: "/v2.Books/GetBook", HttpMethod: http.MethodGet, PathPattern: "/v1/{name=books/*}"},
+			},
+			Conflict: "/v2.Books/GetBook (GET /v1/{name=books/*}) is shadowed by /v1.Books/GetBook (GET /v1/{name=books/*})",
+		},
+		{
+			Name: "shadowed literal",
+			Routes: []alchemy.RouteDesc{
+				{FullMethod: "/v1.Users/GetUser", HttpMethod: http.MethodGet, PathPattern: "/v1/users/{id}"},
+				{FullMethod: "/v1.Users/GetMe", HttpMethod: http.MethodGet, PathPattern: "/v1/users/me"},
+			},
+			Conflict: "/v1.Users/GetMe (GET /v1/users/me) is shadowed by /v1.Users/GetUser (GET /v1/users/{id})",
+		},
+		{
+			Name: "shadowed by double wildcard",
+			Routes: []alchemy.RouteDesc{
+				{FullMethod: "/v1.Files/GetFile", HttpMethod: http.MethodGet, PathPattern: "/v1/{name=files/**}"},
+				{FullMethod: "/v1.Files/GetContent", HttpMethod: http.MethodGet, PathPattern: "/v1/files/{name=*}/content"},
+			},
+			Conflict: "/v1.Files/GetContent (GET /v1/files/{name=*}/content) is shadowed by /v1.Files/GetFile",
+		},
+		{
+			Name: "shadowed by regexp",
+			Routes: []alchemy.RouteDesc{
+				{FullMethod: "/v1.Users/GetUser", HttpMethod: http.MethodGet, PathPattern: "/v1/users/{id:[0-9]+}"},
+				{FullMethod: "/v1.Users/GetRoot", HttpMethod: http.MethodGet, PathPattern: "/v1/users/0"},
+			},
+			Conflict: "/v1.Users/GetRoot (GET /v1/users/0) is shadowed by /v1.Users/GetUser",
+		},
+		{
+			Name: "shadowed additional handler",
+			Routes: []alchemy.RouteDesc{
+				{FullMethod: "/v1.Pages/GetPage", HttpMethod: http.MethodGet, PathPattern: "/{name}"},
+			},
+			Options: []alchemy.HttpOption{
+				alchemy.HttpWithAdditionalHandler(http.MethodGet, "/healthz", http.NotFoundHandler()),
+			},
+			Conflict: "additional handler GET /healthz is shadowed by /v1.Pages/GetPage (GET /{name})",
+		},
+		{
+			Name: "unnamed route",
+			Routes: []alchemy.RouteDesc{
+				{HttpMethod: http.MethodDelete, PathPattern: "/v1/books/{id}"},
+				{HttpMethod: http.MethodDelete, PathPattern: "/v1/books/{name}"},
+			},
+			Conflict: "route DELETE /v1/books/{name} is shadowed by route DELETE /v1/books/{id}",
+		},
+		{
+			Name: "different methods",
+			Routes: []alchemy.RouteDesc{
+				{FullMethod: "/v1.Books/GetBook", HttpMethod: http.MethodGet, PathPattern: "/v1/{name=books/*}"},
+				{FullMethod: "/v1.Books/DeleteBook", HttpMethod: http.MethodDelete, PathPattern: "/v1/{name=books/*}"},
+			},
+		},
+		{
+			Name: "more specific first",
+			Routes: []alchemy.RouteDesc{
+				{FullMethod: "/v1.Users/GetMe", HttpMethod: http.MethodGet, PathPattern: "/v1/users/me"},
+				{FullMethod: "/v1.Users/GetUser", HttpMethod: http.MethodGet, PathPattern: "/v1/users/{id}"},
+			},
+		},
+		{
+			Name: "custom verbs",
+			Routes: []alchemy.RouteDesc{
+				{FullMethod: "/v1.Books/UpdateBook", HttpMethod: http.MethodPost, PathPattern: "/v1/{name=books/*}"},
+				{FullMethod: "/v1.Books/PublishBook", HttpMethod: http.MethodPost, PathPattern: "/v1/{name=books/*}:publish"},
+			},
+		},
+		{
+			Name: "partial overlap",
+			Routes: []alchemy.RouteDesc{
+				{FullMethod: "/v1.Books/GetBook", HttpMethod: http.MethodGet, PathPattern: "/v1/{shelf}/books"},
+				{FullMethod: "/v1.Shelves/GetShelf", HttpMethod: http.MethodGet, PathPattern: "/v1/shelves/{id}"},
+			},
+		},
+		{
+			Name: "warn only",
+			Routes: []alchemy.RouteDesc{
+				{FullMethod: "/v1.Books/GetBook", HttpMethod: http.MethodGet, PathPattern: "/v1/books/{id}"},
+				{FullMethod: "/v2.Books/GetBook", HttpMethod: http.MethodGet, PathPattern: "/v1/books/{id}"},
+			},
+			Options: []alchemy.HttpOption{alchemy.HttpWithRouteConflictsWarnOnly()},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			err := StartWithRoutes(t, tt.Routes, tt.Options...)
+			if len(tt.Conflict) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, alchemy.ErrRouteConflict)
+			assert.ErrorContains(t, err, tt.Conflict)
+		})
+	}
+}
+
+// CaptureWarnings captures the warnings logged by the default logger until the end of the test.
+func CaptureWarnings(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	logger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	t.Cleanup(func() { slog.SetDefault(logger) })
+
+	return &buf
+}
+
+func TestApp_RouteOverlaps(t *testing.T) {
+	cases := []struct {
+		Name    string
+		Routes  []alchemy.RouteDesc
+		Options []alchemy.HttpOption
+		Warning string
+	}{
+		{
+			Name: "partial overlap",
+			Routes: []alchemy.RouteDesc{
+				{FullMethod: "/v1.Items/GetItem", HttpMethod: http.MethodGet, PathPattern: "/v1/{a}/x"},
+				{FullMethod: "/v1.Items/GetY", HttpMethod: http.MethodGet, PathPattern: "/v1/y/{b}"},
+			},
+			Warning: "/v1.Items/GetY (GET /v1/y/{b}) is partially shadowed by /v1.Items/GetItem (GET /v1/{a}/x)",
+		},
+		{
+			Name: "partial overlap by double wildcard",
+			Routes: []alchemy.RouteDesc{
+				{FullMethod: "/v1.Files/GetFile", HttpMethod: http.MethodGet, PathPattern: "/v1/{name=files/**}/raw"},
+				{FullMethod: "/v1.Files/GetDir", HttpMethod: http.MethodGet, PathPattern: "/v1/files/{dir}/{name}"},
+			},
+			Warning: "/v1.Files/GetDir (GET /v1/files/{dir}/{name}) is partially shadowed by /v1.Files/GetFile",
+		},
+		{
+			Name: "partial overlap by regexp",
+			Routes: []alchemy.RouteDesc{
+				{FullMethod: "/v1.Users/GetProfile", HttpMethod: http.MethodGet, PathPattern: "/v1/users/{id:[0-9]+}/profile"},
+				{FullMethod: "/v1.Users/GetRootTab", HttpMethod: http.MethodGet, PathPattern: "/v1/users/0/{tab}"},
+			},
+			Warning: "/v1.Users/GetRootTab (GET /v1/users/0/{tab}) is partially shadowed by /v1.Users/GetProfile",
+		},
+		{
+			Name: "additional handler with another method",
+			Routes: []alchemy.RouteDesc{
+				{FullMethod: "/v1.Pages/GetPage", HttpMethod: http.MethodGet, PathPattern: "/{name}"},
+			},
+			Options: []alchemy.HttpOption{
+				alchemy.HttpWithAdditionalHandler(http.MethodPost, "/webhook", http.NotFoundHandler()),
+			},
+			Warning: "additional handler POST /webhook is shadowed by /v1.Pages/GetPage (GET /{name}), " +
+				"which responds to its requests with 405 Method Not Allowed",
+		},
+		{
+			Name: "additional handler partially with another method",
+			Routes: []alchemy.RouteDesc{
+				{FullMethod: "/v1.Hooks/GetHook", HttpMethod: http.MethodGet, PathPattern: "/hooks/{id}"},
+			},
+			Options: []alchemy.HttpOption{
+				alchemy.HttpWithAdditionalHandler(http.MethodPost, "/hooks/{name:[a-z]+}", http.NotFoundHandler()),
+			},
+			Warning: "additional handler POST /hooks/{name:[a-z]+} is partially shadowed by /v1.Hooks/GetHook (GET /hooks/{id}), " +
+				"which responds to the requests matched by both with 405 Method Not Allowed",
+		},
+		{
+			Name: "disjoint",
+			Routes: []alchemy.RouteDesc{
+				{FullMethod: "/v1.Books/GetBook", HttpMethod: http.MethodGet, PathPattern: "/v1/{name=books/*}"},
+				{FullMethod: "/v1.Books/ListShelves", HttpMethod: http.MethodGet, PathPattern: "/v1/shelves"},
+				{FullMethod: "/v1.Books/DeleteBook", HttpMethod: http.MethodDelete, PathPattern: "/v1/{name=books/*}"},
+				{FullMethod: "/v1.Books/PublishBook", HttpMethod: http.MethodGet, PathPattern: "/v1/{name=books/*}:publish"},
+			},
+			Options: []alchemy.HttpOption{
+				alchemy.HttpWithAdditionalHandler(http.MethodGet, "/healthz", http.NotFoundHandler()),
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			warnings := CaptureWarnings(t)
+			require.NoError(t, StartWithRoutes(t, tt.Routes, tt.Options...))
+
+			if len(tt.Warning) == 0 {
+				assert.NotContains(t, warnings.String(), "route overlap")
+				return
+			}
+			assert.Contains(t, warnings.String(), "route overlap")
+			assert.Contains(t, warnings.String(), tt.Warning)
+		})
+	}
+}
+
+func TestHttpWithRouteConflictsWarnOnly(t *testing.T) {
+	assert.NotNil(t, alchemy.HttpWithRouteConflictsWarnOnly())
+}
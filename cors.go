@@ -0,0 +1,307 @@
+package alchemy
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// HttpWithCors returns an HttpOption that enables the Cross-Origin Resource Sharing
+// for all the requests handled by the server.
+//
+// The preflight requests are answered automatically for every registered route,
+// the allowed methods default to the methods of the routes matching the path.
+// The CORS handling wraps the middlewares added by HttpWithMiddleware, so that
+// the preflight requests are answered before reaching them.
+//
+// Allowing all the origins together with the credentials is rejected, since it
+// would let any site make authenticated requests on behalf of the users.
+func HttpWithCors(options ...CorsOption) HttpOption {
+	return func(hs *httpServer) error {
+		policy := &corsPolicy{}
+		for _, applyCorsOption := range options {
+			if err := applyCorsOption(policy); err != nil {
+				return err
+			}
+		}
+		if policy.allowAllOrigins && policy.allowCredentials {
+			return ErrCorsCredentialsForAllOrigins
+		}
+
+		hs.cors = policy
+		return nil
+	}
+}
+
+// ErrCorsCredentialsForAllOrigins is returned by HttpWithCors when the credentials
+// are allowed for all the origins, the trusted origins must be listed instead.
+var ErrCorsCredentialsForAllOrigins = errors.New("alchemy: CORS credentials cannot be allowed for all origins")
+
+// CorsOption used to configures the CORS policy of the server.
+type CorsOption func(policy *corsPolicy) error
+
+// CorsWithAllowedOrigins configures the origins allowed to make cross-origin requests.
+//
+// An origin may contain a single "*" matching any part of the origin, e.g.
+// "https://*.example.com", a single "*" allows all the origins.
+func CorsWithAllowedOrigins(origins ...string) CorsOption {
+	return func(policy *corsPolicy) error {
+		for _, origin := range origins {
+			if origin == "*" {
+				policy.allowAllOrigins = true
+				continue
+			}
+
+			origin = strings.ToLower(origin)
+			if prefix, suffix, found := strings.Cut(origin, "*"); found {
+				policy.wildcardOrigins = append(policy.wildcardOrigins, [2]string{prefix, suffix})
+			} else {
+				policy.allowedOrigins = append(policy.allowedOrigins, origin)
+			}
+		}
+		return nil
+	}
+}
+
+// CorsWithAllowedOriginRegexps configures the regular expressions matching the
+// origins allowed to make cross-origin requests.
+//
+// The expressions are matched against the whole origin.
+func CorsWithAllowedOriginRegexps(exprs ...string) CorsOption {
+	return func(policy *corsPolicy) error {
+		for _, expr := range exprs {
+			re, err := regexp.Compile("^(?:" + expr + ")$")
+			if err != nil {
+				return err
+			}
+			policy.originRegexps = append(policy.originRegexps, re)
+		}
+		return nil
+	}
+}
+
+// CorsWithAllowedMethods configures the methods allowed for the cross-origin requests,
+// instead of the methods of the routes matching the path.
+func CorsWithAllowedMethods(methods ...string) CorsOption {
+	return func(policy *corsPolicy) error {
+		for _, method := range methods {
+			policy.allowedMethods = append(policy.allowedMethods, strings.ToUpper(method))
+		}
+		return nil
+	}
+}
+
+// CorsWithAllowedHeaders configures the request headers allowed for the cross-origin
+// requests, a single "*" allows all the headers.
+//
+// If not configured, only the headers in DefaultCorsAllowedHeaders are allowed.
+func CorsWithAllowedHeaders(headers ...string) CorsOption {
+	return func(policy *corsPolicy) error {
+		for _, header := range headers {
+			policy.allowedHeaders = append(policy.allowedHeaders, http.CanonicalHeaderKey(header))
+		}
+		return nil
+	}
+}
+
+// CorsWithExposedHeaders configures the response headers exposed to the clients.
+func CorsWithExposedHeaders(headers ...string) CorsOption {
+	return func(policy *corsPolicy) error {
+		for _, header := range headers {
+			policy.exposedHeaders = append(policy.exposedHeaders, http.CanonicalHeaderKey(header))
+		}
+		return nil
+	}
+}
+
+// CorsWithAllowCredentials configures whether the cross-origin requests may
+// include the user credentials like cookies and authorization headers.
+func CorsWithAllowCredentials(allow bool) CorsOption {
+	return func(policy *corsPolicy) error {
+		policy.allowCredentials = allow
+		return nil
+	}
+}
+
+// CorsWithMaxAge configures how long the results of the preflight requests can be cached.
+func CorsWithMaxAge(maxAge time.Duration) CorsOption {
+	return func(policy *corsPolicy) error {
+		policy.maxAge = maxAge
+		return nil
+	}
+}
+
+// DefaultCorsAllowedHeaders are the request headers allowed for the cross-origin
+// requests if no header is configured by CorsWithAllowedHeaders.
+var DefaultCorsAllowedHeaders = []string{"Accept", "Accept-Language", "Content-Language", "Content-Type"}
+
+// corsPolicy represents the CORS policy of the server.
+type corsPolicy struct {
+	allowAllOrigins  bool
+	allowedOrigins   []string
+	wildcardOrigins  [][2]string
+	originRegexps    []*regexp.Regexp
+	allowedMethods   []string
+	allowedHeaders   []string
+	exposedHeaders   []string
+	allowCredentials bool
+	maxAge           time.Duration
+}
+
+// handler returns a handler that applies the policy to the requests before passing
+// them to next, the preflight requests are answered with the methods returned by
+// routeMethods if no method is configured.
+//
+// All the responses vary by the Origin header, including the responses to the
+// requests without it, so that a shared cache never serves the response of a
+// same-origin request to a cross-origin request or the other way round.
+func (p *corsPolicy) handler(next http.Handler, routeMethods func(*http.Request) []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Vary", "Origin")
+		origin := req.Header.Get("Origin")
+		if len(origin) == 0 {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		if req.Method == http.MethodOptions && len(req.Header.Get("Access-Control-Request-Method")) != 0 {
+			methods := p.allowedMethods
+			if len(methods) == 0 {
+				methods = routeMethods(req)
+			}
+			if len(methods) != 0 {
+				p.preflight(w, req, origin, methods)
+				return
+			}
+		}
+
+		if p.allowOrigin(origin) {
+			p.writeAllowOrigin(w, origin)
+			if len(p.exposedHeaders) != 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(p.exposedHeaders, ", "))
+			}
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// preflight answers the preflight request, the CORS headers are omitted
+// if the request is not allowed by the policy.
+func (p *corsPolicy) preflight(w http.ResponseWriter, req *http.Request, origin string, methods []string) {
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+	defer w.WriteHeader(http.StatusNoContent)
+
+	method := strings.ToUpper(req.Header.Get("Access-Control-Request-Method"))
+	if !p.allowOrigin(origin) || !slices.Contains(methods, method) {
+		return
+	}
+
+	var requestedHeaders []string
+	for _, value := range req.Header.Values("Access-Control-Request-Headers") {
+		for _, header := range strings.Split(value, ",") {
+			if header = strings.TrimSpace(header); len(header) != 0 {
+				requestedHeaders = append(requestedHeaders, http.CanonicalHeaderKey(header))
+			}
+		}
+	}
+	allowedHeaders := p.allowedHeaders
+	if len(allowedHeaders) == 0 {
+		allowedHeaders = DefaultCorsAllowedHeaders
+	}
+	if !slices.Contains(allowedHeaders, "*") {
+		for _, header := range requestedHeaders {
+			if !slices.Contains(allowedHeaders, header) {
+				return
+			}
+		}
+	}
+
+	p.writeAllowOrigin(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if len(requestedHeaders) != 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(requestedHeaders, ", "))
+	}
+	if p.maxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(p.maxAge.Seconds())))
+	}
+}
+
+// writeAllowOrigin writes the headers allowing the origin.
+func (p *corsPolicy) writeAllowOrigin(w http.ResponseWriter, origin string) {
+	if p.allowAllOrigins {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.allowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// allowOrigin reports whether the origin is allowed by the policy.
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	if p.allowAllOrigins {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if slices.Contains(p.allowedOrigins, origin) {
+		return true
+	}
+	for _, wildcard := range p.wildcardOrigins {
+		prefix, suffix := wildcard[0], wildcard[1]
+		if len(origin) >= len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+	for _, re := range p.originRegexps {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// corsCandidateMethods are the methods tried when looking for the methods of the routes.
+var corsCandidateMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// routeMethods returns a function that returns the methods of the routes of the
+// routers matching the path of the request.
+func (hs *httpServer) routeMethods(routers ...*mux.Router) func(*http.Request) []string {
+	candidates := slices.Clone(corsCandidateMethods)
+	for _, route := range hs.routes {
+		candidates = append(candidates, route.desc.HttpMethod)
+	}
+	for _, route := range hs.additionalRoutes {
+		candidates = append(candidates, route.HttpMethod)
+	}
+
+	return func(req *http.Request) []string {
+		var methods []string
+		for _, method := range candidates {
+			if slices.Contains(methods, method) {
+				continue
+			}
+
+			probe := req.Clone(req.Context())
+			probe.Method = method
+			for _, router := range routers {
+				var match mux.RouteMatch
+				if router.Match(probe, &match) && match.MatchErr == nil {
+					methods = append(methods, method)
+					break
+				}
+			}
+		}
+		return methods
+	}
+}
@@ -0,0 +1,257 @@
+package alchemy_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/wjiec/alchemy"
+)
+
+func TestHttpWithCors(t *testing.T) {
+	empty := HandleFunc(func(ctx context.Context) (any, error) { return &emptypb.Empty{}, nil })
+	routes := []alchemy.RouteDesc{
+		{HttpMethod: http.MethodGet, PathPattern: "/v1/{name=books/*}", Handler: empty},
+		{HttpMethod: http.MethodDelete, PathPattern: "/v1/{name=books/*}", Handler: empty},
+		{HttpMethod: http.MethodPost, PathPattern: "/v1/{name=shelves/*}:publish", Handler: empty},
+	}
+
+	baseUrl := ServeHttp(t, routes,
+		alchemy.HttpWithAdditionalHandler(http.MethodPut, "/uploads/{name}", http.NotFoundHandler()),
+		alchemy.HttpWithCors(
+			alchemy.CorsWithAllowedOrigins("https://app.example.com", "https://*.preview.example.com"),
+			alchemy.CorsWithAllowedOriginRegexps(`http://localhost:\d+`),
+			alchemy.CorsWithAllowedHeaders("Content-Type", "Authorization"),
+			alchemy.CorsWithExposedHeaders("X-Request-Id"),
+			alchemy.CorsWithAllowCredentials(true),
+			alchemy.CorsWithMaxAge(10*time.Minute),
+		),
+	)
+
+	cases := []struct {
+		Name    string
+		Method  string
+		Path    string
+		Headers map[string]string
+		Status  int
+		Want    map[string]string
+	}{
+		{
+			Name:   "preflight",
+			Method: http.MethodOptions,
+			Path:   "/v1/books/b1",
+			Headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "DELETE",
+				"Access-Control-Request-Headers": "content-type, authorization",
+			},
+			Status: http.StatusNoContent,
+			Want: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Methods":     "GET, DELETE",
+				"Access-Control-Allow-Headers":     "Content-Type, Authorization",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Max-Age":           "600",
+			},
+		},
+		{
+			Name:   "preflight custom verb",
+			Method: http.MethodOptions,
+			Path:   "/v1/shelves/s1:publish",
+			Headers: map[string]string{
+				"Origin":                        "https://pr-1.preview.example.com",
+				"Access-Control-Request-Method": "POST",
+			},
+			Status: http.StatusNoContent,
+			Want: map[string]string{
+				"Access-Control-Allow-Origin":  "https://pr-1.preview.example.com",
+				"Access-Control-Allow-Methods": "POST",
+			},
+		},
+		{
+			Name:   "preflight additional handler",
+			Method: http.MethodOptions,
+			Path:   "/uploads/avatar.png",
+			Headers: map[string]string{
+				"Origin":                        "http://localhost:3000",
+				"Access-Control-Request-Method": "PUT",
+			},
+			Status: http.StatusNoContent,
+			Want: map[string]string{
+				"Access-Control-Allow-Origin":  "http://localhost:3000",
+				"Access-Control-Allow-Methods": "PUT",
+			},
+		},
+		{
+			Name:   "preflight disallowed origin",
+			Method: http.MethodOptions,
+			Path:   "/v1/books/b1",
+			Headers: map[string]string{
+				"Origin":                        "https://evil.example.org",
+				"Access-Control-Request-Method": "GET",
+			},
+			Status: http.StatusNoContent,
+			Want:   map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Allow-Methods": ""},
+		},
+		{
+			Name:   "preflight disallowed method",
+			Method: http.MethodOptions,
+			Path:   "/v1/books/b1",
+			Headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "PUT",
+			},
+			Status: http.StatusNoContent,
+			Want:   map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Allow-Methods": ""},
+		},
+		{
+			Name:   "preflight disallowed header",
+			Method: http.MethodOptions,
+			Path:   "/v1/books/b1",
+			Headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "GET",
+				"Access-Control-Request-Headers": "X-Debug",
+			},
+			Status: http.StatusNoContent,
+			Want:   map[string]string{"Access-Control-Allow-Origin": "", "Access-Control-Allow-Methods": ""},
+		},
+		{
+			Name:   "preflight unknown route",
+			Method: http.MethodOptions,
+			Path:   "/v2/books",
+			Headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "GET",
+			},
+			Status: http.StatusNotFound,
+		},
+		{
+			Name:    "actual request",
+			Method:  http.MethodGet,
+			Path:    "/v1/books/b1",
+			Headers: map[string]string{"Origin": "https://app.example.com"},
+			Status:  http.StatusOK,
+			Want: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "X-Request-Id",
+				"Vary":                             "Origin",
+			},
+		},
+		{
+			Name:    "actual request disallowed origin",
+			Method:  http.MethodGet,
+			Path:    "/v1/books/b1",
+			Headers: map[string]string{"Origin": "https://evil.example.org"},
+			Status:  http.StatusOK,
+			Want:    map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			Name:   "same origin request",
+			Method: http.MethodGet,
+			Path:   "/v1/books/b1",
+			Status: http.StatusOK,
+			Want:   map[string]string{"Access-Control-Allow-Origin": "", "Vary": "Origin"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.Method, baseUrl+tt.Path, nil)
+			for key, value := range tt.Headers {
+				req.Header.Set(key, value)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, tt.Status, resp.StatusCode)
+			for key, value := range tt.Want {
+				assert.Equal(t, value, resp.Header.Get(key), key)
+			}
+		})
+	}
+}
+
+func TestHttpWithCors_AllowAllOrigins(t *testing.T) {
+	baseUrl := ServeHttp(t, []alchemy.RouteDesc{
+		{
+			HttpMethod:  http.MethodPost,
+			PathPattern: "/v1/books",
+			Handler:     HandleFunc(func(ctx context.Context) (any, error) { return &emptypb.Empty{}, nil }),
+		},
+	}, alchemy.HttpWithCors(alchemy.CorsWithAllowedOrigins("*"), alchemy.CorsWithAllowedMethods("post", "put")))
+
+	req, _ := http.NewRequest(http.MethodOptions, baseUrl+"/v1/books", nil)
+	req.Header.Set("Origin", "https://anywhere.example.com")
+	req.Header.Set("Access-Control-Request-Method", "PUT")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "POST, PUT", resp.Header.Get("Access-Control-Allow-Methods"))
+	assert.Empty(t, resp.Header.Get("Access-Control-Allow-Credentials"))
+}
+
+func TestHttpWithCors_AllowAllOriginsWithCredentials(t *testing.T) {
+	_, err := alchemy.New(t.Name(), alchemy.WithHttpServer(alchemy.TCP(":0"),
+		alchemy.HttpWithCors(alchemy.CorsWithAllowCredentials(true), alchemy.CorsWithAllowedOrigins("*")),
+	))
+	assert.ErrorIs(t, err, alchemy.ErrCorsCredentialsForAllOrigins)
+}
+
+func TestHttpWithCors_DefaultAllowedHeaders(t *testing.T) {
+	baseUrl := ServeHttp(t, []alchemy.RouteDesc{
+		{
+			HttpMethod:  http.MethodPost,
+			PathPattern: "/v1/books",
+			Handler:     HandleFunc(func(ctx context.Context) (any, error) { return &emptypb.Empty{}, nil }),
+		},
+	}, alchemy.HttpWithCors(alchemy.CorsWithAllowedOrigins("https://app.example.com")))
+
+	cases := []struct {
+		Headers string
+		Allowed string
+	}{
+		{Headers: "content-type", Allowed: "Content-Type"},
+		{Headers: "accept, content-language", Allowed: "Accept, Content-Language"},
+		{Headers: "content-type, x-debug"},
+		{Headers: "authorization"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Headers, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodOptions, baseUrl+"/v1/books", nil)
+			req.Header.Set("Origin", "https://app.example.com")
+			req.Header.Set("Access-Control-Request-Method", "POST")
+			req.Header.Set("Access-Control-Request-Headers", tt.Headers)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+			assert.Equal(t, tt.Allowed, resp.Header.Get("Access-Control-Allow-Headers"))
+			if len(tt.Allowed) == 0 {
+				assert.Empty(t, resp.Header.Get("Access-Control-Allow-Origin"))
+			}
+		})
+	}
+}
+
+func TestCorsWithAllowedOriginRegexps(t *testing.T) {
+	_, err := alchemy.New(t.Name(), alchemy.WithHttpServer(alchemy.TCP(":0"),
+		alchemy.HttpWithCors(alchemy.CorsWithAllowedOriginRegexps(`(`)),
+	))
+	assert.Error(t, err)
+}
@@ -0,0 +1,173 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	// ErrNoContent is returned when a Download message carries no content.
+	ErrNoContent = errors.New("download: no content")
+
+	// ErrUnknownContent is returned when a Download message references a content
+	// that was not attached within the current request.
+	ErrUnknownContent = errors.New("download: unknown content")
+)
+
+// Contents is a request-scoped registry of the contents streamed to the client,
+// keyed by opaque identifiers.
+type Contents struct {
+	mu       sync.Mutex
+	contents map[string]io.Reader
+}
+
+// NewContents creates and returns an empty content registry.
+func NewContents() *Contents {
+	return &Contents{}
+}
+
+// Add registers the content to the registry and returns its opaque identifier.
+func (c *Contents) Add(r io.Reader) string {
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+	id := hex.EncodeToString(buf[:])
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.contents == nil {
+		c.contents = make(map[string]io.Reader)
+	}
+	c.contents[id] = r
+
+	return id
+}
+
+// Take removes the content registered with the identifier from the registry and returns it.
+func (c *Contents) Take(id string) (io.Reader, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	r, found := c.contents[id]
+	delete(c.contents, id)
+	return r, found
+}
+
+// Close closes all the contents which are never taken from the registry.
+func (c *Contents) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	for id, r := range c.contents {
+		if closer, ok := r.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+		delete(c.contents, id)
+	}
+	return errors.Join(errs...)
+}
+
+// contentsKey is how we find the [*Contents] in a context.Context.
+type contentsKey struct{}
+
+// NewContextWithContents returns a new Context, derived from ctx, which carries
+// the provided [*Contents].
+func NewContextWithContents(ctx context.Context, contents *Contents) context.Context {
+	return context.WithValue(ctx, contentsKey{}, contents)
+}
+
+// ContentsFromContext returns a [*Contents] from ctx.
+func ContentsFromContext(ctx context.Context) (*Contents, bool) {
+	if raw := ctx.Value(contentsKey{}); raw != nil {
+		return raw.(*Contents), true
+	}
+	return nil, false
+}
+
+// Attach sets the content of the Download message to the reader.
+//
+// The reader is registered to the content registry carried by ctx and streamed to the
+// client after the handler returns, range requests are only supported if it implements
+// [io.ReadSeeker], and it is closed afterward if it implements [io.Closer]. The
+// conditional requests are supported with the Etag and LastModified either way.
+//
+// If there is no content registry in ctx, for example when the request comes from
+// a gRPC client, the reader is read into memory instead.
+func Attach(ctx context.Context, dl *Download, r io.Reader) error {
+	if contents, found := ContentsFromContext(ctx); found {
+		dl.Content = &Download_ContentId{ContentId: contents.Add(r)}
+		return nil
+	}
+
+	if closer, ok := r.(io.Closer); ok {
+		defer func() { _ = closer.Close() }()
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	dl.Content = &Download_Data{Data: data}
+	return nil
+}
+
+// Open returns the reader of the content carried by the Download message.
+//
+// The caller takes the ownership of the reader and should close it if it
+// implements [io.Closer].
+func Open(ctx context.Context, dl *Download) (io.Reader, error) {
+	switch v := dl.GetContent().(type) {
+	case *Download_Data:
+		return bytes.NewReader(v.Data), nil
+	case *Download_ContentId:
+		if contents, found := ContentsFromContext(ctx); found {
+			if r, found := contents.Take(v.ContentId); found {
+				return r, nil
+			}
+		}
+		return nil, ErrUnknownContent
+	default:
+		return nil, ErrNoContent
+	}
+}
+
+// NewFromFile creates a Download message streaming the named file.
+//
+// The filename, content type, modification time and an entity tag are derived
+// from the file, and can be overridden by the caller afterward. The entity tag
+// is strong, derived from the modification time and size of the file, so that
+// it satisfies the If-Range condition of the resumed range requests.
+func NewFromFile(ctx context.Context, name string) (*Download, error) {
+	fp, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := fp.Stat()
+	if err != nil {
+		_ = fp.Close()
+		return nil, err
+	}
+
+	dl := &Download{
+		ContentType:  mime.TypeByExtension(filepath.Ext(name)),
+		Filename:     filepath.Base(name),
+		Etag:         fmt.Sprintf(`"%x-%x"`, stat.ModTime().UnixNano(), stat.Size()),
+		LastModified: timestamppb.New(stat.ModTime()),
+	}
+	if err = Attach(ctx, dl, fp); err != nil {
+		return nil, err
+	}
+	return dl, nil
+}
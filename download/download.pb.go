@@ -0,0 +1,226 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: download/download.proto
+
+// buf:lint:ignore PACKAGE_DIRECTORY_MATCH
+// buf:lint:ignore PACKAGE_VERSION_SUFFIX
+
+package download
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Download struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// media type of the content, sent as the Content-Type header
+	ContentType string `protobuf:"bytes,1,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	// name of the file suggested to the client in the Content-Disposition header
+	Filename string `protobuf:"bytes,2,opt,name=filename,proto3" json:"filename,omitempty"`
+	// if set, the content is displayed inline instead of being downloaded as an attachment
+	Inline bool `protobuf:"varint,3,opt,name=inline,proto3" json:"inline,omitempty"`
+	// entity tag of the content, used for conditional and range requests
+	Etag string `protobuf:"bytes,4,opt,name=etag,proto3" json:"etag,omitempty"`
+	// modification time of the content, used for conditional and range requests
+	LastModified *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=last_modified,json=lastModified,proto3" json:"last_modified,omitempty"`
+	// Types that are valid to be assigned to Content:
+	//
+	//	*Download_Data
+	//	*Download_ContentId
+	Content       isDownload_Content `protobuf_oneof:"content"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Download) Reset() {
+	*x = Download{}
+	mi := &file_download_download_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Download) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Download) ProtoMessage() {}
+
+func (x *Download) ProtoReflect() protoreflect.Message {
+	mi := &file_download_download_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Download.ProtoReflect.Descriptor instead.
+func (*Download) Descriptor() ([]byte, []int) {
+	return file_download_download_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Download) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *Download) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *Download) GetInline() bool {
+	if x != nil {
+		return x.Inline
+	}
+	return false
+}
+
+func (x *Download) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+func (x *Download) GetLastModified() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastModified
+	}
+	return nil
+}
+
+func (x *Download) GetContent() isDownload_Content {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *Download) GetData() []byte {
+	if x != nil {
+		if x, ok := x.Content.(*Download_Data); ok {
+			return x.Data
+		}
+	}
+	return nil
+}
+
+func (x *Download) GetContentId() string {
+	if x != nil {
+		if x, ok := x.Content.(*Download_ContentId); ok {
+			return x.ContentId
+		}
+	}
+	return ""
+}
+
+type isDownload_Content interface {
+	isDownload_Content()
+}
+
+type Download_Data struct {
+	// the whole content kept in memory
+	Data []byte `protobuf:"bytes,6,opt,name=data,proto3,oneof"`
+}
+
+type Download_ContentId struct {
+	// opaque identifier of the content in the request-scoped content registry
+	ContentId string `protobuf:"bytes,7,opt,name=content_id,json=contentId,proto3,oneof"`
+}
+
+func (*Download_Data) isDownload_Content() {}
+
+func (*Download_ContentId) isDownload_Content() {}
+
+var File_download_download_proto protoreflect.FileDescriptor
+
+const file_download_download_proto_rawDesc = "" +
+	"\n" +
+	"\x17download/download.proto\x12\x10alchemy.download\x1a\x1fgoogle/protobuf/timestamp.proto\"\xf8\x01\n" +
+	"\bDownload\x12!\n" +
+	"\fcontent_type\x18\x01 \x01(\tR\vcontentType\x12\x1a\n" +
+	"\bfilename\x18\x02 \x01(\tR\bfilename\x12\x16\n" +
+	"\x06inline\x18\x03 \x01(\bR\x06inline\x12\x12\n" +
+	"\x04etag\x18\x04 \x01(\tR\x04etag\x12?\n" +
+	"\rlast_modified\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\flastModified\x12\x14\n" +
+	"\x04data\x18\x06 \x01(\fH\x00R\x04data\x12\x1f\n" +
+	"\n" +
+	"content_id\x18\a \x01(\tH\x00R\tcontentIdB\t\n" +
+	"\acontentB\xa9\x01\n" +
+	"\x14com.alchemy.downloadB\rDownloadProtoP\x01Z!github.com/wjiec/alchemy/download\xa2\x02\x03ADX\xaa\x02\x10Alchemy.Download\xca\x02\x10Alchemy\\Download\xe2\x02\x1cAlchemy\\Download\\GPBMetadata\xea\x02\x11Alchemy::Downloadb\x06proto3"
+
+var (
+	file_download_download_proto_rawDescOnce sync.Once
+	file_download_download_proto_rawDescData []byte
+)
+
+func file_download_download_proto_rawDescGZIP() []byte {
+	file_download_download_proto_rawDescOnce.Do(func() {
+		file_download_download_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_download_download_proto_rawDesc), len(file_download_download_proto_rawDesc)))
+	})
+	return file_download_download_proto_rawDescData
+}
+
+var file_download_download_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_download_download_proto_goTypes = []any{
+	(*Download)(nil),              // 0: alchemy.download.Download
+	(*timestamppb.Timestamp)(nil), // 1: google.protobuf.Timestamp
+}
+var file_download_download_proto_depIdxs = []int32{
+	1, // 0: alchemy.download.Download.last_modified:type_name -> google.protobuf.Timestamp
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_download_download_proto_init() }
+func file_download_download_proto_init() {
+	if File_download_download_proto != nil {
+		return
+	}
+	file_download_download_proto_msgTypes[0].OneofWrappers = []any{
+		(*Download_Data)(nil),
+		(*Download_ContentId)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_download_download_proto_rawDesc), len(file_download_download_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_download_download_proto_goTypes,
+		DependencyIndexes: file_download_download_proto_depIdxs,
+		MessageInfos:      file_download_download_proto_msgTypes,
+	}.Build()
+	File_download_download_proto = out.File
+	file_download_download_proto_goTypes = nil
+	file_download_download_proto_depIdxs = nil
+}
@@ -0,0 +1,70 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttach(t *testing.T) {
+	t.Run("registry", func(t *testing.T) {
+		ctx := NewContextWithContents(context.Background(), NewContents())
+
+		var dl Download
+		if assert.NoError(t, Attach(ctx, &dl, bytes.NewReader([]byte("hello")))) {
+			assert.NotEmpty(t, dl.GetContentId())
+
+			r, err := Open(ctx, &dl)
+			if assert.NoError(t, err) {
+				content, _ := io.ReadAll(r)
+				assert.Equal(t, "hello", string(content))
+			}
+
+			_, err = Open(ctx, &dl)
+			assert.ErrorIs(t, err, ErrUnknownContent)
+		}
+	})
+
+	t.Run("no registry", func(t *testing.T) {
+		var dl Download
+		if assert.NoError(t, Attach(context.Background(), &dl, bytes.NewReader([]byte("hello")))) {
+			assert.Equal(t, []byte("hello"), dl.GetData())
+		}
+	})
+}
+
+func TestOpen(t *testing.T) {
+	t.Run("no content", func(t *testing.T) {
+		_, err := Open(context.Background(), &Download{})
+		assert.ErrorIs(t, err, ErrNoContent)
+	})
+
+	t.Run("forged", func(t *testing.T) {
+		ctx := NewContextWithContents(context.Background(), NewContents())
+		_, err := Open(ctx, &Download{Content: &Download_ContentId{ContentId: "foobar"}})
+		assert.ErrorIs(t, err, ErrUnknownContent)
+	})
+}
+
+func TestNewFromFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "report.csv")
+	require.NoError(t, os.WriteFile(name, []byte("a,b,c"), 0644))
+
+	contents := NewContents()
+	defer func() { _ = contents.Close() }()
+
+	dl, err := NewFromFile(NewContextWithContents(context.Background(), contents), name)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "report.csv", dl.Filename)
+		assert.Equal(t, "text/csv; charset=utf-8", dl.ContentType)
+		assert.Regexp(t, `^"[0-9a-f]+-5"$`, dl.Etag)
+		assert.NotNil(t, dl.LastModified)
+		assert.NotEmpty(t, dl.GetContentId())
+	}
+}
@@ -3,21 +3,29 @@ package alchemy
 import (
 	"context"
 	"net"
+	"sync"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
+
+	"github.com/wjiec/alchemy/errs"
 )
 
 // WithGrpcServer sets the gRPC server for the App, enabling the application to handle
 // gRPC requests using the specified server configuration.
 func WithGrpcServer(addr Addr, options ...GrpcOption) AppOption {
 	return func(app *App) error {
-		app.grpcServer = &grpcServer{addr: addr}
+		app.grpcServer = &grpcServer{addr: addr, ready: make(chan struct{})}
 		for _, applyGrpcOption := range options {
 			if err := applyGrpcOption(app.grpcServer); err != nil {
 				return err
 			}
 		}
+		if binder, ok := addr.(flagBinder); ok {
+			binder.bindFlags(app.root.PersistentFlags())
+		}
 
 		app.grpcServer.unaryInterceptor = app.wrapGrpcUnaryInterceptor()
 		return nil
@@ -28,15 +36,22 @@ func WithGrpcServer(addr Addr, options ...GrpcOption) AppOption {
 type grpcServer struct {
 	addr       Addr
 	reflection bool
+	health     *health.Server
 
 	options          []grpc.ServerOption
 	services         []func(grpc.ServiceRegistrar)
 	unaryInterceptor grpc.UnaryServerInterceptor
+
+	mu       sync.Mutex
+	server   *grpc.Server
+	stopped  bool
+	listener net.Listener
+	ready    chan struct{}
 }
 
 // Start initiates the gRPC server and begins serving requests.
 func (gs *grpcServer) Start(ctx context.Context) error {
-	l, err := net.Listen(gs.addr.Network(ctx), gs.addr.String(ctx))
+	l, err := listen(ctx, gs.addr)
 	if err != nil {
 		return err
 	}
@@ -54,13 +69,60 @@ func (gs *grpcServer) Start(ctx context.Context) error {
 	if gs.reflection {
 		reflection.Register(server)
 	}
+	if gs.health != nil {
+		healthpb.RegisterHealthServer(server, gs.health)
+	}
+
+	gs.mu.Lock()
+	if gs.stopped {
+		gs.mu.Unlock()
+		return l.Close()
+	}
+	gs.server = server
+	gs.listener = l
+	close(gs.ready)
+	gs.mu.Unlock()
+
+	// Start serving on the configured listener.
+	return errs.Ignore(server.Serve(l), grpc.ErrServerStopped)
+}
+
+// Ready returns a channel which is closed once the server is listening.
+func (gs *grpcServer) Ready() <-chan struct{} { return gs.ready }
+
+// Listener returns the listener of the server, or nil if it is not listening.
+func (gs *grpcServer) Listener() net.Listener {
+	gs.mu.Lock()
+	defer gs.mu.Unlock()
+
+	return gs.listener
+}
 
+// Shutdown stops the gRPC server gracefully, the server is stopped forcibly
+// if the context is done before all the pending RPCs are finished.
+func (gs *grpcServer) Shutdown(ctx context.Context) error {
+	gs.mu.Lock()
+	gs.stopped = true
+	server := gs.server
+	gs.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+
+	done := make(chan struct{})
 	go func() {
-		<-ctx.Done()
 		server.GracefulStop()
+		close(done)
 	}()
-	// Start serving on the configured listener.
-	return server.Serve(l)
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		server.Stop()
+		<-done
+		return ctx.Err()
+	}
 }
 
 // GrpcOption used to configure a gRPC server instance.
@@ -74,6 +136,15 @@ func GrpcWithReflection(reflection bool) GrpcOption {
 	}
 }
 
+// GrpcWithHealthService registers the standard gRPC health checking service,
+// which reports NOT_SERVING for all the services once the App starts shutting down.
+func GrpcWithHealthService() GrpcOption {
+	return func(server *grpcServer) error {
+		server.health = health.NewServer()
+		return nil
+	}
+}
+
 // GrpcWithServerOption appends fallback [grpc.ServerOption] to the gRPC server's configuration.
 func GrpcWithServerOption(options ...grpc.ServerOption) GrpcOption {
 	return func(server *grpcServer) error {
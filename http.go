@@ -2,11 +2,15 @@ package alchemy
 
 import (
 	"context"
+	"io"
+	"mime"
 	"net"
 	"net/http"
 	"net/textproto"
+	"reflect"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -16,7 +20,10 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/wjiec/alchemy/bizerr"
+	"github.com/wjiec/alchemy/download"
 	"github.com/wjiec/alchemy/errs"
+	"github.com/wjiec/alchemy/internal/pattern"
+	"github.com/wjiec/alchemy/multipart"
 )
 
 const (
@@ -28,13 +35,18 @@ const (
 func WithHttpServer(addr Addr, options ...HttpOption) AppOption {
 	return func(app *App) error {
 		app.httpServer = &httpServer{
+			name: app.name,
 			addr: addr,
 
 			codec:    NewHttpDynamicCodec(),
 			fallback: mux.NewRouter(),
+			ready:    make(chan struct{}),
 
+			healthy:               app.Healthy,
 			unaryInterceptor:      app.wrapGrpcUnaryInterceptor(),
 			gracefulTimeout:       DefaultGracefulShutdownTimeout,
+			routeMaxBodySizes:     make(map[string]int64),
+			multipartMaxMemory:    DefaultMultipartMaxMemory,
 			outgoingHeaderMatcher: DefaultOutgoingHeaderMatcher,
 		}
 		for _, applyHttpOption := range options {
@@ -42,6 +54,9 @@ func WithHttpServer(addr Addr, options ...HttpOption) AppOption {
 				return err
 			}
 		}
+		if binder, ok := addr.(flagBinder); ok {
+			binder.bindFlags(app.root.PersistentFlags())
+		}
 
 		return nil
 	}
@@ -49,62 +64,173 @@ func WithHttpServer(addr Addr, options ...HttpOption) AppOption {
 
 // grpcServer represents a HTTP server.
 type httpServer struct {
+	name string
 	addr Addr
 
 	codec    CodecFactory
 	fallback *mux.Router
 
-	services              []func(*mux.Router)
+	routes                []httpRoute
+	additionalRoutes      []RouteDesc
+	warnRouteConflicts    bool
+	openapiDocs           [][]byte
+	middlewares           []HttpMiddleware
+	cors                  *corsPolicy
 	gracefulTimeout       time.Duration
+	maxBodySize           int64
+	routeMaxBodySizes     map[string]int64
+	multipartMaxMemory    int64
 	unaryInterceptor      grpc.UnaryServerInterceptor
 	errorHandlers         []HttpErrorHandler
 	respDecorators        []HttpResponseDecorator
 	metadataAnnotators    []HttpMetadataAnnotator
 	outgoingHeaderMatcher HttpOutgoingHeaderMatcher
+	healthy               func() bool
+
+	mu       sync.Mutex
+	server   *http.Server
+	stopped  bool
+	listener net.Listener
+	ready    chan struct{}
+}
+
+// httpRoute represents a route registered to the HTTP server.
+type httpRoute struct {
+	desc *RouteDesc
+	srv  any
 }
 
 // Start initiates the HTTP server and begins serving requests.
 func (hs *httpServer) Start(ctx context.Context) error {
-	l, err := net.Listen(hs.addr.Network(ctx), hs.addr.String(ctx))
+	router, err := hs.newRouter()
 	if err != nil {
 		return err
 	}
 
+	l, err := listen(ctx, hs.addr)
+	if err != nil {
+		return err
+	}
+
+	handler := hs.applyMiddlewares(router)
+	if hs.cors != nil {
+		handler = hs.cors.handler(handler, hs.routeMethods(router, hs.fallback))
+	}
+
+	return errs.Ignore(hs.serve(l, handler), http.ErrServerClosed)
+}
+
+// applyMiddlewares wraps the handler with the middlewares of the server,
+// the first added middleware is the outermost one.
+func (hs *httpServer) applyMiddlewares(h http.Handler) http.Handler {
+	for i := len(hs.middlewares) - 1; i >= 0; i-- {
+		h = hs.middlewares[i](h)
+	}
+	return h
+}
+
+// newRouter creates a router that dispatches the requests to the registered routes,
+// the requests matching no route are dispatched to the fallback router.
+//
+// The routes with custom verbs are registered first, so that the verbs are not
+// swallowed by the variables of the routes without verb.
+func (hs *httpServer) newRouter() (*mux.Router, error) {
+	templates := make([]*pattern.Template, len(hs.routes))
+	for i, route := range hs.routes {
+		template, err := pattern.ParseTemplate(route.desc.PathPattern)
+		if err != nil {
+			return nil, err
+		}
+		templates[i] = template
+	}
+
 	router := mux.NewRouter()
-	for _, registerService := range hs.services {
-		registerService(router)
+	for _, withVerb := range []bool{true, false} {
+		for i, route := range hs.routes {
+			if templates[i].HasVerb() == withVerb {
+				router.Handle(templates[i].MuxPattern(), hs.wrapHttpHandler(route.desc, route.srv)).
+					Methods(route.desc.HttpMethod)
+			}
+		}
 	}
 	router.NotFoundHandler = hs.fallback
 
-	return errs.Ignore(hs.serve(ctx, l, router), http.ErrServerClosed)
+	return router, nil
 }
 
 // serve starts the HTTP server using the provided listener and handler.
-//
-// It also sets up graceful shutdown triggered by the context's cancellation.
-func (hs *httpServer) serve(ctx context.Context, l net.Listener, h http.Handler) error {
-	server := http.Server{Handler: h}
-
-	go func() {
-		<-ctx.Done()
-		shutdownCtx, forceShutdown := context.WithTimeout(context.Background(), hs.gracefulTimeout)
-		defer forceShutdown()
+func (hs *httpServer) serve(l net.Listener, h http.Handler) error {
+	server := &http.Server{Handler: h}
 
-		_ = server.Shutdown(shutdownCtx)
-	}()
+	hs.mu.Lock()
+	if hs.stopped {
+		hs.mu.Unlock()
+		return l.Close()
+	}
+	hs.server = server
+	hs.listener = l
+	close(hs.ready)
+	hs.mu.Unlock()
 
 	return server.Serve(l)
 }
 
+// Ready returns a channel which is closed once the server is listening.
+func (hs *httpServer) Ready() <-chan struct{} { return hs.ready }
+
+// Listener returns the listener of the server, or nil if it is not listening.
+func (hs *httpServer) Listener() net.Listener {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+
+	return hs.listener
+}
+
+// Shutdown stops the HTTP server gracefully, the server is closed forcibly if the
+// context is done or the graceful shutdown timeout is exceeded before all the
+// connections become idle.
+func (hs *httpServer) Shutdown(ctx context.Context) error {
+	hs.mu.Lock()
+	hs.stopped = true
+	server := hs.server
+	hs.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, hs.gracefulTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		_ = server.Close()
+		return err
+	}
+	return nil
+}
+
 type outgoingMetadataKey struct{}
 
 // wrapHttpHandler creates an HTTP handler that wraps a gRPC method handler.
 func (hs *httpServer) wrapHttpHandler(route *RouteDesc, srv any) http.Handler {
+	maxBodySize := hs.maxBodySize
+	if limit, found := hs.routeMaxBodySizes[routeKey(route.HttpMethod, route.PathPattern)]; found {
+		maxBodySize = limit
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if maxBodySize > 0 {
+			req.Body = http.MaxBytesReader(w, req.Body, maxBodySize)
+		}
 		defer func() { _ = req.Body.Close() }()
 		ctx := NewContextWithRouteDesc(req.Context(), route)
 		ctx = NewContextWithHttpRequest(ctx, req)
 		ctx = NewContextWithHttpResponseWriter(ctx, w)
+		ctx = newContextWithMultipartMaxMemory(ctx, hs.multipartMaxMemory)
+		ctx = multipart.NewContextWithUploads(ctx, multipart.NewUploads())
+
+		contents := download.NewContents()
+		defer func() { _ = contents.Close() }()
+		ctx = download.NewContextWithContents(ctx, contents)
 
 		incomingMetadata := metadata.MD{}
 		for _, annotator := range hs.metadataAnnotators {
@@ -117,14 +243,40 @@ func (hs *httpServer) wrapHttpHandler(route *RouteDesc, srv any) http.Handler {
 		ctx = metadata.NewOutgoingContext(ctx, outgoingMetadata)
 
 		req = req.WithContext(ctx)
+		defer removeMultipartForm(req)
+		if maxBodySize > 0 && req.ContentLength > maxBodySize {
+			hs.writeResponse(ctx, w, req, nil, ErrRequestBodyTooLarge)
+			return
+		}
+
 		resp, err := route.Handler(srv, ctx, hs.codec.Decoder(req), hs.unaryInterceptor)
 		hs.writeResponse(ctx, w, req, resp, err)
 	})
 }
 
+// removeMultipartForm removes the temporary files associated with the
+// multipart form parsed from the request, if any.
+func removeMultipartForm(req *http.Request) {
+	if req.MultipartForm != nil {
+		_ = req.MultipartForm.RemoveAll()
+	}
+}
+
+// routeKey returns the key used to identify a route by its HTTP method and path pattern.
+func routeKey(method, pattern string) string {
+	return method + " " + pattern
+}
+
 // writeResponse writes the response data to the HTTP response writer.
 func (hs *httpServer) writeResponse(ctx context.Context, w http.ResponseWriter, req *http.Request, resp any, err error) {
+	if dl, ok := resp.(*download.Download); ok && err == nil {
+		if err = hs.writeDownload(ctx, w, req, dl); err == nil {
+			return
+		}
+	}
+
 	if err == nil {
+		resp = responseHttpBody(ctx, resp)
 		if enc := hs.codec.Encoder(w, req); enc != nil {
 			var buf []byte
 			if buf, err = enc(resp); err == nil {
@@ -140,6 +292,144 @@ func (hs *httpServer) writeResponse(ctx context.Context, w http.ResponseWriter,
 	}
 }
 
+// responseHttpBody returns the [httpbody.HttpBody] selected by the response_body of
+// the route so that it is written as is, or resp otherwise.
+//
+// The response_body selecting a field of another type is not applied, the whole
+// response is written instead.
+func responseHttpBody(ctx context.Context, resp any) any {
+	desc, found := RouteDescFromContext(ctx)
+	if !found || desc.ResponseField.Accessor == nil {
+		return resp
+	}
+	if rv := reflect.ValueOf(resp); rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return resp
+	}
+
+	if body, ok := httpBodyOf(desc.ResponseField.Accessor(resp)); ok {
+		return body
+	}
+	return resp
+}
+
+// writeDownload streams the content of the download to the HTTP response writer.
+//
+// The conditional requests are handled with the ETag and Last-Modified of the
+// download, while the range requests are only handled if the content is seekable,
+// a non-seekable content is always sent in full.
+func (hs *httpServer) writeDownload(ctx context.Context, w http.ResponseWriter, req *http.Request, dl *download.Download) error {
+	content, err := download.Open(ctx, dl)
+	if err != nil {
+		return err
+	}
+	if closer, ok := content.(io.Closer); ok {
+		defer func() { _ = closer.Close() }()
+	}
+
+	if len(dl.ContentType) != 0 {
+		w.Header().Set("Content-Type", dl.ContentType)
+	}
+	if len(dl.Filename) != 0 || dl.Inline {
+		disposition := "attachment"
+		if dl.Inline {
+			disposition = "inline"
+		}
+
+		var params map[string]string
+		if len(dl.Filename) != 0 {
+			params = map[string]string{"filename": dl.Filename}
+		}
+		w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, params))
+	}
+	if etag := dl.Etag; len(etag) != 0 {
+		if !strings.HasPrefix(etag, `"`) && !strings.HasPrefix(etag, `W/"`) {
+			etag = `"` + etag + `"`
+		}
+		w.Header().Set("ETag", etag)
+	}
+
+	var modtime time.Time
+	if dl.LastModified != nil {
+		modtime = dl.LastModified.AsTime()
+	}
+
+	hs.forwardResponseServerMetadata(ctx, w)
+	if seeker, ok := content.(io.ReadSeeker); ok {
+		http.ServeContent(w, req, dl.Filename, modtime, seeker)
+		return nil
+	}
+
+	if !modtime.IsZero() {
+		w.Header().Set("Last-Modified", modtime.UTC().Format(http.TimeFormat))
+	}
+	switch code := checkPreconditions(req, w.Header().Get("ETag"), modtime); code {
+	case http.StatusNotModified:
+		w.Header().Del("Content-Type")
+		w.WriteHeader(code)
+		return nil
+	case http.StatusPreconditionFailed:
+		w.WriteHeader(code)
+		return nil
+	}
+
+	_, _ = io.Copy(w, content)
+	return nil
+}
+
+// checkPreconditions evaluates the conditional headers of the request against the
+// entity tag and the modification time of the content as RFC 9110 section 13.2.2,
+// it returns 304 or 412 if the content should not be sent, or 0 otherwise.
+func checkPreconditions(req *http.Request, etag string, modtime time.Time) int {
+	// The time in the headers is in seconds.
+	modtime = modtime.Truncate(time.Second)
+
+	if ifMatch := req.Header.Get("If-Match"); len(ifMatch) != 0 {
+		if !etagMatches(ifMatch, etag, false) {
+			return http.StatusPreconditionFailed
+		}
+	} else if since, err := http.ParseTime(req.Header.Get("If-Unmodified-Since")); err == nil && !modtime.IsZero() {
+		if modtime.After(since) {
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	safe := req.Method == http.MethodGet || req.Method == http.MethodHead
+	if ifNoneMatch := req.Header.Get("If-None-Match"); len(ifNoneMatch) != 0 {
+		if etagMatches(ifNoneMatch, etag, true) {
+			if safe {
+				return http.StatusNotModified
+			}
+			return http.StatusPreconditionFailed
+		}
+	} else if since, err := http.ParseTime(req.Header.Get("If-Modified-Since")); err == nil && !modtime.IsZero() && safe {
+		if !modtime.After(since) {
+			return http.StatusNotModified
+		}
+	}
+	return 0
+}
+
+// etagMatches reports whether the entity tag matches any of the entity tags listed
+// in the header, the weak entity tags only match if weak comparison is used.
+func etagMatches(header, etag string, weak bool) bool {
+	if len(etag) == 0 {
+		return false
+	}
+
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		switch {
+		case candidate == "*":
+			return true
+		case weak && strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/"):
+			return true
+		case !weak && candidate == etag && !strings.HasPrefix(etag, "W/"):
+			return true
+		}
+	}
+	return false
+}
+
 // defaultErrorHandler processes and writes error responses for HTTP requests.
 func (hs *httpServer) defaultErrorHandler(ctx context.Context, w http.ResponseWriter, req *http.Request, err error) {
 	// Apply all registered error handlers in sequence
@@ -147,22 +437,26 @@ func (hs *httpServer) defaultErrorHandler(ctx context.Context, w http.ResponseWr
 		err = errHandler(req.Context(), req, err)
 	}
 
-	// Set appropriate HTTP status code from biz error if present
+	// Set appropriate HTTP status code from biz error if present, or from the gRPC code otherwise
+	statusCode := runtime.HTTPStatusFromCode(status.Code(err))
 	if bizErr, ok := bizerr.FromError(err); ok {
-		w.WriteHeader(int(bizErr.Status()))
+		statusCode = int(bizErr.Status())
 	}
 
+	// The headers must be set before the status code is written
 	hs.forwardResponseServerMetadata(ctx, w)
-	if enc := hs.codec.Encoder(w, req); enc != nil {
-		statusErr := status.Convert(err)
-		statusErr.Details()
-
-		if buf, wErr := enc(status.Convert(err).Proto()); wErr != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			_, _ = w.Write([]byte(`{"code": 13, "message": "internal server error"}`))
-		} else {
-			_, _ = w.Write(buf)
-		}
+	enc := hs.codec.Encoder(w, req)
+	if enc == nil {
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	if buf, wErr := enc(status.Convert(err).Proto()); wErr != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"code": 13, "message": "internal server error"}`))
+	} else {
+		w.WriteHeader(statusCode)
+		_, _ = w.Write(buf)
 	}
 }
 
@@ -187,6 +481,55 @@ type HttpOption func(server *httpServer) error
 func HttpWithAdditionalHandler(method, pattern string, handler http.Handler) HttpOption {
 	return func(server *httpServer) error {
 		server.fallback.Handle(pattern, handler).Methods(method)
+		server.additionalRoutes = append(server.additionalRoutes, RouteDesc{HttpMethod: method, PathPattern: pattern})
+		return nil
+	}
+}
+
+// HttpMiddleware wraps an [http.Handler] to process the requests before and after it.
+type HttpMiddleware func(http.Handler) http.Handler
+
+// HttpWithMiddleware returns an HttpOption that adds a middleware wrapping all the
+// requests handled by the server, including the ones dispatched to the additional
+// handlers and the not-found and method-not-allowed handlers.
+//
+// Multiple middlewares can be added, the first added middleware is the outermost one
+// and sees the requests first.
+func HttpWithMiddleware(middleware HttpMiddleware) HttpOption {
+	return func(hs *httpServer) error {
+		hs.middlewares = append(hs.middlewares, middleware)
+		return nil
+	}
+}
+
+// HttpWithPathPrefixMiddleware returns an HttpOption that adds a middleware wrapping
+// the requests whose path is the prefix or starts with the prefix followed by a slash.
+//
+// It is ordered along with the middlewares added by HttpWithMiddleware, the requests
+// out of its scope are passed to the next handler as-is.
+func HttpWithPathPrefixMiddleware(prefix string, middleware HttpMiddleware) HttpOption {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return HttpWithMiddleware(func(next http.Handler) http.Handler {
+		scoped := middleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if path := req.URL.Path; path == prefix || strings.HasPrefix(path, prefix+"/") {
+				scoped.ServeHTTP(w, req)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	})
+}
+
+// HttpWithRouteConflictsWarnOnly configures the server to log the route conflicts
+// detected at startup as warnings instead of failing to start.
+//
+// A route conflicts with an earlier route if all its requests are matched by the
+// earlier route, so that it can never be reached. The partial overlaps of the
+// routes are logged as warnings regardless of this option.
+func HttpWithRouteConflictsWarnOnly() HttpOption {
+	return func(server *httpServer) error {
+		server.warnRouteConflicts = true
 		return nil
 	}
 }
@@ -194,7 +537,8 @@ func HttpWithAdditionalHandler(method, pattern string, handler http.Handler) Htt
 // HttpWithGracefulShutdownTimeout configures the timeout duration for graceful server shutdown.
 //
 // This option sets how long the server will wait for existing connections
-// to complete before forcefully terminating them.
+// to complete before forcefully terminating them, which is also bounded by the
+// time left of the shutdown timeout of the App, see WithShutdownTimeout.
 func HttpWithGracefulShutdownTimeout(timeout time.Duration) HttpOption {
 	return func(server *httpServer) error {
 		server.gracefulTimeout = timeout
@@ -202,6 +546,42 @@ func HttpWithGracefulShutdownTimeout(timeout time.Duration) HttpOption {
 	}
 }
 
+// HttpWithMaxBodySize configures the maximum size in bytes of the request bodies
+// accepted by the server, a non-positive value disables the limit. The request
+// bodies are not limited by default.
+//
+// Requests exceeding the limit are rejected with [ErrRequestBodyTooLarge],
+// which is reported to the client as 413 Request Entity Too Large.
+func HttpWithMaxBodySize(limit int64) HttpOption {
+	return func(server *httpServer) error {
+		server.maxBodySize = limit
+		return nil
+	}
+}
+
+// HttpWithRouteMaxBodySize configures the maximum size in bytes of the request bodies
+// accepted by the route registered with the given HTTP method and path pattern.
+//
+// It takes precedence over the limit configured by HttpWithMaxBodySize, a non-positive
+// value disables the limit for the route.
+func HttpWithRouteMaxBodySize(method, pattern string, limit int64) HttpOption {
+	return func(server *httpServer) error {
+		server.routeMaxBodySizes[routeKey(method, pattern)] = limit
+		return nil
+	}
+}
+
+// HttpWithMultipartMaxMemory configures the number of bytes of a multipart form kept in
+// memory while parsing, the remainder of the file parts are spilled to temporary files.
+//
+// The temporary files are removed after the handler returns.
+func HttpWithMultipartMaxMemory(size int64) HttpOption {
+	return func(server *httpServer) error {
+		server.multipartMaxMemory = size
+		return nil
+	}
+}
+
 // httpRequestContextKey is how we find the [*http.Request] in a context.Context.
 type httpRequestContextKey struct{}
 
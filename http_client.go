@@ -0,0 +1,323 @@
+package alchemy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/wjiec/alchemy/bizerr"
+)
+
+// HttpClient invokes the methods of the services over HTTP by their routes, it
+// is used by the generated HTTP clients of the services.
+//
+// The path parameters are taken from the request message, the body is either
+// the request field of the route or the whole message except for the GET and
+// DELETE requests, and the other fields are sent as query parameters. The
+// outgoing metadata of the context is sent as the request headers.
+type HttpClient struct {
+	baseURL string
+	client  *http.Client
+	marshal runtime.JSONPb
+}
+
+// NewHttpClient creates an HttpClient sending the requests to the baseURL,
+// e.g. "https://api.example.com".
+func NewHttpClient(baseURL string, options ...HttpClientOption) *HttpClient {
+	c := &HttpClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  http.DefaultClient,
+		marshal: runtime.JSONPb{UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true}},
+	}
+	for _, applyOption := range options {
+		applyOption(c)
+	}
+
+	return c
+}
+
+// HttpClientOption used to configure an HttpClient.
+type HttpClientOption func(c *HttpClient)
+
+// HttpClientWithHttpClient configures the http.Client used to send the requests,
+// http.DefaultClient is used by default.
+func HttpClientWithHttpClient(client *http.Client) HttpClientOption {
+	return func(c *HttpClient) {
+		c.client = client
+	}
+}
+
+// Invoke sends the request message by the route and decodes the response into out,
+// the response is decoded as the whole message as it is written by the HTTP server.
+//
+// The errors responded by the server are returned as a *bizerr.Error if they carry
+// an HTTP status, or a gRPC status error otherwise. Unlike grpc.ClientConn.Invoke,
+// it takes no grpc.CallOption, the response headers are not returned.
+func (c *HttpClient) Invoke(ctx context.Context, route *RouteDesc, in, out proto.Message) error {
+	req, err := c.newRequest(ctx, route, in)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return c.decodeError(resp, data)
+	}
+
+	if body, ok := httpBodyOf(out); ok {
+		body.ContentType = resp.Header.Get("Content-Type")
+		body.Data = data
+		return nil
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if err = c.marshal.Unmarshal(data, out); err != nil {
+		return status.Errorf(codes.Internal, "alchemy: decode response: %v", err)
+	}
+	return nil
+}
+
+// newRequest creates the HTTP request of the request message by the route.
+func (c *HttpClient) newRequest(ctx context.Context, route *RouteDesc, in proto.Message) (*http.Request, error) {
+	msg := in.ProtoReflect()
+	path, err := expandPathPattern(route.PathPattern, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	excludes := make(map[string]bool)
+	for _, name := range route.PathParameters {
+		excludes[name] = true
+	}
+
+	var body io.Reader
+	var contentType string
+	switch {
+	case len(route.RequestField.Name) != 0:
+		excludes[route.RequestField.Name] = true
+		body, contentType, err = c.encodeBody(route.RequestField.Accessor(in))
+	case route.HttpMethod != http.MethodGet && route.HttpMethod != http.MethodDelete:
+		body, contentType, err = c.encodeBody(in)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := make(url.Values)
+	if body == nil || len(route.RequestField.Name) != 0 {
+		encodeQuery(query, msg, "", excludes)
+	}
+	if len(query) != 0 {
+		path += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, route.HttpMethod, c.baseURL+path, body)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if len(contentType) != 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", c.marshal.ContentType(nil))
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for key, values := range md {
+		if strings.HasSuffix(key, "-bin") {
+			continue
+		}
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	return req, nil
+}
+
+// encodeBody encodes the value as the request body.
+func (c *HttpClient) encodeBody(v any) (io.Reader, string, error) {
+	if isHttpBody(v) {
+		body, _ := httpBodyOf(v)
+		return bytes.NewReader(body.GetData()), body.GetContentType(), nil
+	}
+
+	data, err := c.marshal.Marshal(v)
+	if err != nil {
+		return nil, "", status.Errorf(codes.InvalidArgument, "alchemy: encode request: %v", err)
+	}
+	return bytes.NewReader(data), c.marshal.ContentType(v), nil
+}
+
+// decodeError decodes the error responded by the server.
+func (c *HttpClient) decodeError(resp *http.Response, data []byte) error {
+	var st spb.Status
+	if err := c.marshal.Unmarshal(data, &st); err != nil || st.GetCode() == int32(codes.OK) {
+		return status.Errorf(codes.Unknown, "alchemy: unexpected HTTP status %s", resp.Status)
+	}
+
+	err := status.ErrorProto(&st)
+	if bizErr, ok := bizerr.FromError(err); ok {
+		return bizErr
+	}
+	return err
+}
+
+// expandPathPattern returns the path of the pattern with the variables replaced
+// by the fields of the message, e.g. "/v1/{name=shelves/*}" to "/v1/shelves/1".
+func expandPathPattern(pattern string, msg protoreflect.Message) (string, error) {
+	var sb strings.Builder
+	for len(pattern) != 0 {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			sb.WriteString(pattern)
+			break
+		}
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			return "", status.Errorf(codes.Internal, "alchemy: invalid path pattern %q", pattern)
+		}
+		sb.WriteString(pattern[:start])
+
+		name, template, _ := strings.Cut(pattern[start+1:start+end], "=")
+		value, ok := fieldValueOf(msg, name)
+		if !ok || len(value) == 0 {
+			return "", status.Errorf(codes.InvalidArgument, "missing path parameter %s", name)
+		}
+		if strings.Contains(template, "/") || strings.Contains(template, "**") {
+			segments := strings.Split(value, "/")
+			for i, segment := range segments {
+				segments[i] = url.PathEscape(segment)
+			}
+			sb.WriteString(strings.Join(segments, "/"))
+		} else {
+			sb.WriteString(url.PathEscape(value))
+		}
+		pattern = pattern[start+end+1:]
+	}
+	return sb.String(), nil
+}
+
+// fieldValueOf returns the text of the scalar field at the path of the message,
+// e.g. "book.name".
+func fieldValueOf(msg protoreflect.Message, path string) (string, bool) {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		field := msg.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if field == nil {
+			return "", false
+		}
+		if i == len(names)-1 {
+			if field.IsList() || field.IsMap() || field.Message() != nil {
+				return "", false
+			}
+			return formatScalar(field, msg.Get(field)), true
+		}
+		if field.Message() == nil || field.IsList() || field.IsMap() || !msg.Has(field) {
+			return "", false
+		}
+		msg = msg.Get(field).Message()
+	}
+	return "", false
+}
+
+// encodeQuery adds the populated fields of the message to the query, except for
+// the fields whose paths are excluded.
+func encodeQuery(query url.Values, msg protoreflect.Message, prefix string, excludes map[string]bool) {
+	msg.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		path := prefix + string(field.Name())
+		if excludes[path] {
+			return true
+		}
+
+		switch {
+		case field.IsMap():
+			value.Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+				if field.MapValue().Message() == nil {
+					query.Add(path+"["+key.String()+"]", formatScalar(field.MapValue(), value))
+				}
+				return true
+			})
+		case field.IsList():
+			list := value.List()
+			for i := 0; i < list.Len(); i++ {
+				if text, ok := formatQueryValue(field, list.Get(i)); ok {
+					query.Add(path, text)
+				}
+			}
+		case field.Message() != nil && !isWellKnownType(field.Message()):
+			encodeQuery(query, value.Message(), path+".", excludes)
+		default:
+			if text, ok := formatQueryValue(field, value); ok {
+				query.Add(path, text)
+			}
+		}
+		return true
+	})
+}
+
+// formatQueryValue returns the text of a scalar or well-known type value.
+func formatQueryValue(field protoreflect.FieldDescriptor, value protoreflect.Value) (string, bool) {
+	if field.Message() == nil {
+		return formatScalar(field, value), true
+	}
+	if !isWellKnownType(field.Message()) {
+		return "", false
+	}
+
+	data, err := protojson.Marshal(value.Message().Interface())
+	if err != nil {
+		return "", false
+	}
+	if text, err := strconv.Unquote(string(data)); err == nil {
+		return text, true
+	}
+	return string(data), true
+}
+
+// formatScalar returns the text of a scalar value.
+func formatScalar(field protoreflect.FieldDescriptor, value protoreflect.Value) string {
+	switch field.Kind() {
+	case protoreflect.EnumKind:
+		if enum := field.Enum().Values().ByNumber(value.Enum()); enum != nil {
+			return string(enum.Name())
+		}
+		return strconv.Itoa(int(value.Enum()))
+	case protoreflect.BytesKind:
+		return base64.URLEncoding.EncodeToString(value.Bytes())
+	case protoreflect.FloatKind:
+		return strconv.FormatFloat(value.Float(), 'g', -1, 32)
+	case protoreflect.DoubleKind:
+		return strconv.FormatFloat(value.Float(), 'g', -1, 64)
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
+
+// isWellKnownType reports whether the message is a well-known type which is
+// sent as a single query parameter, e.g. google.protobuf.Timestamp.
+func isWellKnownType(msg protoreflect.MessageDescriptor) bool {
+	return msg.ParentFile().Package() == "google.protobuf"
+}
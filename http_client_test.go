@@ -0,0 +1,158 @@
+package alchemy_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/wjiec/alchemy"
+	"github.com/wjiec/alchemy/bizerr"
+	"github.com/wjiec/alchemy/internal/testpb"
+)
+
+func TestHttpClient_Invoke(t *testing.T) {
+	routes := []alchemy.RouteDesc{
+		{
+			HttpMethod:     http.MethodGet,
+			PathPattern:    "/v1/{string_value=shelves/*/books/*}",
+			Handler:        EchoHandler("GetBook"),
+			PathParameters: []string{"string_value"},
+		},
+		{
+			HttpMethod:  http.MethodPost,
+			PathPattern: "/v1/books",
+			Handler:     EchoHandler("CreateBook"),
+		},
+		{
+			HttpMethod:  http.MethodPatch,
+			PathPattern: "/v1/books/{string_value}",
+			Handler:     EchoHandler("UpdateBook"),
+			RequestField: alchemy.KeyPath{
+				Name:     "nested_value",
+				Accessor: func(v any) any { return &v.(*testpb.Proto3Message).NestedValue },
+			},
+			PathParameters: []string{"string_value"},
+		},
+		{
+			HttpMethod:  http.MethodDelete,
+			PathPattern: "/v1/books/{string_value}",
+			Handler: HandleFunc(func(ctx context.Context) (any, error) {
+				return nil, bizerr.New(uint32(codes.NotFound), http.StatusNotFound, "book not found")
+			}),
+			PathParameters: []string{"string_value"},
+		},
+		{
+			HttpMethod:  http.MethodGet,
+			PathPattern: "/v1/metadata",
+			Handler: HandleFunc(func(ctx context.Context) (any, error) {
+				md, _ := metadata.FromIncomingContext(ctx)
+				return &testpb.Proto3Message{RepeatedString: md.Get("x-request-id")}, nil
+			}),
+		},
+		{
+			HttpMethod:  http.MethodPost,
+			PathPattern: "/v1/books:publish",
+			Handler: HandleFunc(func(ctx context.Context) (any, error) {
+				return nil, status.Error(codes.PermissionDenied, "permission denied")
+			}),
+		},
+	}
+	baseUrl := ServeHttp(t, routes, alchemy.HttpWihMetadataAnnotator(func(ctx context.Context, req *http.Request, md metadata.MD) {
+		md.Set("x-request-id", req.Header.Values("X-Request-Id")...)
+	}))
+	client := alchemy.NewHttpClient(baseUrl+"/", alchemy.HttpClientWithHttpClient(http.DefaultClient))
+
+	t.Run("path and query", func(t *testing.T) {
+		var out testpb.Proto3Message
+		err := client.Invoke(context.Background(), &routes[0], &testpb.Proto3Message{
+			StringValue:    "shelves/s 1/books/b1",
+			Int32Value:     32,
+			BoolValue:      true,
+			RepeatedString: []string{"a", "b"},
+			NestedValue:    &testpb.Proto3Message{Int64Value: 64},
+		}, &out)
+		require.NoError(t, err)
+
+		assert.True(t, proto.Equal(&testpb.Proto3Message{
+			StringValue:    "shelves/s 1/books/b1",
+			Int32Value:     32,
+			BoolValue:      true,
+			RepeatedString: []string{"a", "b", "GetBook"},
+			NestedValue:    &testpb.Proto3Message{Int64Value: 64},
+		}, &out), out.String())
+	})
+
+	t.Run("body", func(t *testing.T) {
+		var out testpb.Proto3Message
+		err := client.Invoke(context.Background(), &routes[1], &testpb.Proto3Message{StringValue: "b1", DoubleValue: 1.5}, &out)
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(&testpb.Proto3Message{
+			StringValue:    "b1",
+			DoubleValue:    1.5,
+			RepeatedString: []string{"CreateBook"},
+		}, &out), out.String())
+	})
+
+	t.Run("request field", func(t *testing.T) {
+		var out testpb.Proto3Message
+		err := client.Invoke(context.Background(), &routes[2], &testpb.Proto3Message{
+			StringValue: "b1",
+			Int32Value:  32,
+			NestedValue: &testpb.Proto3Message{StringValue: "nested"},
+		}, &out)
+		require.NoError(t, err)
+		assert.Equal(t, "b1", out.GetStringValue())
+		assert.Equal(t, int32(32), out.GetInt32Value())
+		assert.Equal(t, "nested", out.GetNestedValue().GetStringValue())
+	})
+
+	t.Run("missing path parameter", func(t *testing.T) {
+		err := client.Invoke(context.Background(), &routes[0], &testpb.Proto3Message{}, &testpb.Proto3Message{})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		err := client.Invoke(context.Background(), &routes[3], &testpb.Proto3Message{StringValue: "b1"}, &testpb.Proto3Message{})
+		if bizErr, ok := err.(*bizerr.Error); assert.True(t, ok, err) {
+			assert.Equal(t, uint32(codes.NotFound), bizErr.Code())
+			assert.Equal(t, uint32(http.StatusNotFound), bizErr.Status())
+			assert.Equal(t, "book not found", bizErr.Error())
+		}
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("status error", func(t *testing.T) {
+		err := client.Invoke(context.Background(), &routes[5], &testpb.Proto3Message{}, &testpb.Proto3Message{})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.Equal(t, "permission denied", status.Convert(err).Message())
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "r1")
+
+		var out testpb.Proto3Message
+		require.NoError(t, client.Invoke(ctx, &routes[4], &testpb.Proto3Message{}, &out))
+		assert.Equal(t, []string{"r1"}, out.GetRepeatedString())
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		refused := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return nil, errors.New("connection refused")
+			},
+		}}
+
+		err := alchemy.NewHttpClient(baseUrl, alchemy.HttpClientWithHttpClient(refused)).
+			Invoke(context.Background(), &routes[1], &testpb.Proto3Message{}, &testpb.Proto3Message{})
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
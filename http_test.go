@@ -1,22 +1,69 @@
 package alchemy_test
 
 import (
+	"bytes"
 	"context"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/wjiec/alchemy"
 	"github.com/wjiec/alchemy/bizerr"
+	"github.com/wjiec/alchemy/download"
+	"github.com/wjiec/alchemy/internal/testpb"
+	alchemymultipart "github.com/wjiec/alchemy/multipart"
 )
 
+// ServeHttp starts an app serving the routes over HTTP on a random port and returns its base URL.
+func ServeHttp(t *testing.T, routes []alchemy.RouteDesc, options ...alchemy.HttpOption) string {
+	return ServeHttpServices(t, []*alchemy.ServiceDesc{{Routes: routes}}, options...)
+}
+
+// ServeHttpServices starts an app serving the services over HTTP on a random port and returns its base URL.
+func ServeHttpServices(t *testing.T, descs []*alchemy.ServiceDesc, options ...alchemy.HttpOption) string {
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0"), options...),
+		alchemy.WithServiceRegister(func(s alchemy.ServiceRegistrar, srv any) {
+			for _, desc := range descs {
+				s.RegisterService(desc, srv)
+			}
+		}, any(nil)),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Start(ctx) }()
+	t.Cleanup(func() { cancel(); <-done })
+
+	require.NoError(t, app.WaitReady(ctx))
+
+	return "http://" + app.HttpAddr().String()
+}
+
+// HandleFunc returns a gRPC method handler which responds with the result of fn.
+func HandleFunc(fn func(ctx context.Context) (any, error)) grpc.MethodHandler {
+	return func(_ any, ctx context.Context, _ func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+		return fn(ctx)
+	}
+}
+
 func TestWithHttpServer(t *testing.T) {
 	app, err := alchemy.New(t.Name(),
 		alchemy.WithHttpServer(alchemy.TCP(":0")),
@@ -35,6 +82,320 @@ func TestHttpWithGracefulShutdownTimeout(t *testing.T) {
 	assert.NotNil(t, alchemy.HttpWithGracefulShutdownTimeout(time.Second))
 }
 
+func TestHttpWithMaxBodySize(t *testing.T) {
+	assert.NotNil(t, alchemy.HttpWithMaxBodySize(1<<20))
+}
+
+// DecodeFunc returns a gRPC method handler which decodes the request into a
+// testpb.Proto3Message and responds with the result of fn.
+func DecodeFunc(fn func(ctx context.Context, in *testpb.Proto3Message) (any, error)) grpc.MethodHandler {
+	return func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+		var in testpb.Proto3Message
+		if err := dec(&in); err != nil {
+			return nil, err
+		}
+		return fn(ctx, &in)
+	}
+}
+
+func TestHttpWithRouteMaxBodySize(t *testing.T) {
+	echo := DecodeFunc(func(ctx context.Context, in *testpb.Proto3Message) (any, error) { return in, nil })
+	baseUrl := ServeHttp(t, []alchemy.RouteDesc{
+		{HttpMethod: http.MethodPost, PathPattern: "/v1/messages", Handler: echo},
+		{HttpMethod: http.MethodPost, PathPattern: "/v1/uploads", Handler: echo},
+	},
+		alchemy.HttpWithMaxBodySize(64),
+		alchemy.HttpWithRouteMaxBodySize(http.MethodPost, "/v1/uploads", 1024),
+	)
+
+	message := func(size int) string { return `{"string_value": "` + strings.Repeat("x", size) + `"}` }
+	cases := []struct {
+		Name    string
+		Path    string
+		Body    io.Reader
+		Status  int
+		Message string
+	}{
+		{Name: "server limit", Path: "/v1/messages", Body: strings.NewReader(message(128)), Status: http.StatusRequestEntityTooLarge, Message: "request body too large"},
+		{Name: "route limit", Path: "/v1/uploads", Body: strings.NewReader(message(128)), Status: http.StatusOK, Message: strings.Repeat("x", 128)},
+		{Name: "route limit exceeded", Path: "/v1/uploads", Body: strings.NewReader(message(2048)), Status: http.StatusRequestEntityTooLarge, Message: "request body too large"},
+		// the length of the chunked body is unknown until it is read by the decoder
+		{Name: "chunked", Path: "/v1/uploads", Body: io.MultiReader(strings.NewReader(message(2048))), Status: http.StatusRequestEntityTooLarge, Message: "request body too large"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			resp, err := http.Post(baseUrl+tt.Path, "application/json", tt.Body)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, tt.Status, resp.StatusCode)
+			assert.Contains(t, string(body), tt.Message)
+		})
+	}
+}
+
+func TestHttpWithMultipartMaxMemory(t *testing.T) {
+	tempFiles := make(chan string, 1)
+	baseUrl := ServeHttp(t, []alchemy.RouteDesc{
+		{
+			HttpMethod:  http.MethodPost,
+			PathPattern: "/v1/uploads",
+			Handler: DecodeFunc(func(ctx context.Context, in *testpb.Proto3Message) (any, error) {
+				files, err := alchemymultipart.GetUploadsFromMultipartContext(ctx, in.MultipartValue)
+				if err != nil || len(files) != 1 {
+					return nil, status.Errorf(codes.InvalidArgument, "unexpected uploads: %v", err)
+				}
+
+				fp, err := files[0].Open()
+				if err != nil {
+					return nil, err
+				}
+				defer func() { _ = fp.Close() }()
+
+				// the file exceeding the memory threshold is kept in a temporary file
+				if osFile, ok := fp.(*os.File); ok {
+					tempFiles <- osFile.Name()
+				}
+				return &emptypb.Empty{}, nil
+			}),
+		},
+	}, alchemy.HttpWithMultipartMaxMemory(1))
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("multipart_value", "hello.txt")
+	require.NoError(t, err)
+	_, err = fw.Write(bytes.Repeat([]byte("hello world"), 1024))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	resp, err := http.Post(baseUrl+"/v1/uploads", mw.FormDataContentType(), &body)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	select {
+	case name := <-tempFiles:
+		require.Eventually(t, func() bool {
+			_, err := os.Stat(name)
+			return os.IsNotExist(err)
+		}, time.Second, 10*time.Millisecond, "the temporary file %s is not removed", name)
+	default:
+		t.Fatal("the upload is not kept in a temporary file")
+	}
+}
+
+func TestHttpServer_Download(t *testing.T) {
+	modtime := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	baseUrl := ServeHttp(t, []alchemy.RouteDesc{
+		{
+			HttpMethod:  http.MethodGet,
+			PathPattern: "/export",
+			Handler: HandleFunc(func(ctx context.Context) (any, error) {
+				dl := &download.Download{
+					ContentType:  "text/plain",
+					Filename:     "export.txt",
+					Etag:         "v1",
+					LastModified: timestamppb.New(modtime),
+				}
+				err := download.Attach(ctx, dl, bytes.NewReader([]byte("hello world")))
+				return dl, err
+			}),
+		},
+	})
+
+	t.Run("full", func(t *testing.T) {
+		resp, err := http.Get(baseUrl + "/export")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "hello world", string(body))
+		assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=export.txt`, resp.Header.Get("Content-Disposition"))
+		assert.Equal(t, `"v1"`, resp.Header.Get("ETag"))
+		assert.Equal(t, modtime.Format(http.TimeFormat), resp.Header.Get("Last-Modified"))
+	})
+
+	t.Run("range", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, baseUrl+"/export", nil)
+		req.Header.Set("Range", "bytes=6-")
+		req.Header.Set("If-Range", `"v1"`)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+		assert.Equal(t, "world", string(body))
+		assert.Equal(t, "bytes 6-10/11", resp.Header.Get("Content-Range"))
+	})
+
+	t.Run("stale range", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, baseUrl+"/export", nil)
+		req.Header.Set("Range", "bytes=6-")
+		req.Header.Set("If-Range", `"v0"`)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "hello world", string(body))
+	})
+
+	t.Run("not modified", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, baseUrl+"/export", nil)
+		req.Header.Set("If-None-Match", `"v1"`)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	})
+}
+
+func TestHttpServer_DownloadNotSeekable(t *testing.T) {
+	modtime := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	baseUrl := ServeHttp(t, []alchemy.RouteDesc{
+		{
+			HttpMethod:  http.MethodGet,
+			PathPattern: "/export",
+			Handler: HandleFunc(func(ctx context.Context) (any, error) {
+				dl := &download.Download{
+					ContentType:  "text/plain",
+					Etag:         "v1",
+					LastModified: timestamppb.New(modtime),
+				}
+				err := download.Attach(ctx, dl, io.MultiReader(strings.NewReader("hello world")))
+				return dl, err
+			}),
+		},
+	})
+
+	cases := []struct {
+		Name   string
+		Header http.Header
+		Status int
+	}{
+		{Name: "range", Header: http.Header{"Range": {"bytes=6-"}}, Status: http.StatusOK},
+		{Name: "if-none-match", Header: http.Header{"If-None-Match": {`W/"v0", "v1"`}}, Status: http.StatusNotModified},
+		{Name: "if-none-match changed", Header: http.Header{"If-None-Match": {`"v0"`}}, Status: http.StatusOK},
+		{Name: "if-modified-since", Header: http.Header{"If-Modified-Since": {modtime.Format(http.TimeFormat)}}, Status: http.StatusNotModified},
+		{Name: "if-modified-since changed", Header: http.Header{"If-Modified-Since": {modtime.Add(-time.Second).Format(http.TimeFormat)}}, Status: http.StatusOK},
+		{Name: "if-match", Header: http.Header{"If-Match": {`"v1"`}}, Status: http.StatusOK},
+		{Name: "if-match changed", Header: http.Header{"If-Match": {`"v0"`}}, Status: http.StatusPreconditionFailed},
+		{Name: "if-match weak", Header: http.Header{"If-Match": {`W/"v1"`}}, Status: http.StatusPreconditionFailed},
+		{Name: "if-unmodified-since", Header: http.Header{"If-Unmodified-Since": {modtime.Format(http.TimeFormat)}}, Status: http.StatusOK},
+		{Name: "if-unmodified-since changed", Header: http.Header{"If-Unmodified-Since": {modtime.Add(-time.Second).Format(http.TimeFormat)}}, Status: http.StatusPreconditionFailed},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, baseUrl+"/export", nil)
+			req.Header = c.Header
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, c.Status, resp.StatusCode)
+			assert.Equal(t, `"v1"`, resp.Header.Get("ETag"))
+			if c.Status == http.StatusOK {
+				assert.Equal(t, "hello world", string(body))
+			} else {
+				assert.Empty(t, body)
+			}
+		})
+	}
+}
+
+func TestHttpServer_ResponseHttpBody(t *testing.T) {
+	type Image struct{ Body *httpbody.HttpBody }
+	body := alchemy.KeyPath{
+		Name:     "body",
+		Accessor: func(v any) any { return &v.(*Image).Body },
+	}
+	baseUrl := ServeHttp(t, []alchemy.RouteDesc{
+		{
+			HttpMethod:    http.MethodGet,
+			PathPattern:   "/image",
+			ResponseField: body,
+			Handler: HandleFunc(func(ctx context.Context) (any, error) {
+				return &Image{Body: &httpbody.HttpBody{ContentType: "image/png", Data: []byte{0x89, 'P', 'N', 'G'}}}, nil
+			}),
+		},
+		{
+			HttpMethod:    http.MethodGet,
+			PathPattern:   "/empty",
+			ResponseField: body,
+			Handler: HandleFunc(func(ctx context.Context) (any, error) {
+				return &Image{}, nil
+			}),
+		},
+	})
+
+	resp, err := http.Get(baseUrl + "/image")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	data, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	assert.Equal(t, []byte{0x89, 'P', 'N', 'G'}, data)
+
+	resp, err = http.Get(baseUrl + "/empty")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	data, _ = io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, data)
+}
+
+func TestHttpServer_DownloadFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "export.txt")
+	require.NoError(t, os.WriteFile(name, []byte("hello world"), 0644))
+
+	baseUrl := ServeHttp(t, []alchemy.RouteDesc{
+		{
+			HttpMethod:  http.MethodGet,
+			PathPattern: "/export",
+			Handler: HandleFunc(func(ctx context.Context) (any, error) {
+				return download.NewFromFile(ctx, name)
+			}),
+		},
+	})
+
+	resp, err := http.Get(baseUrl + "/export")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// the download is resumed from the offset if the file is not changed
+	req, _ := http.NewRequest(http.MethodGet, baseUrl+"/export", nil)
+	req.Header.Set("Range", "bytes=6-")
+	req.Header.Set("If-Range", etag)
+
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, "world", string(body))
+}
+
 func TestNewContextWithHttpRequest(t *testing.T) {
 	assert.NotNil(t, alchemy.NewContextWithHttpRequest(context.Background(), &http.Request{}))
 }
@@ -115,6 +476,42 @@ func TestHttpWithErrorHandler(t *testing.T) {
 	}))
 }
 
+func TestHttpServer_Error(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Err    error
+		Status int
+		Code   string
+	}{
+		{Name: "grpc status", Err: status.Error(codes.NotFound, "not found"), Status: http.StatusNotFound, Code: `"code":5`},
+		{Name: "plain error", Err: io.ErrUnexpectedEOF, Status: http.StatusInternalServerError, Code: `"code":2`},
+		{Name: "business error", Err: bizerr.New(10001, http.StatusConflict, "conflict"), Status: http.StatusConflict, Code: `"code":10001`},
+	}
+
+	var routes []alchemy.RouteDesc
+	for i, tt := range cases {
+		routes = append(routes, alchemy.RouteDesc{
+			HttpMethod:  http.MethodGet,
+			PathPattern: "/v1/errors/" + string(rune('a'+i)),
+			Handler:     HandleFunc(func(ctx context.Context) (any, error) { return nil, tt.Err }),
+		})
+	}
+	baseUrl := ServeHttp(t, routes)
+
+	for i, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			resp, err := http.Get(baseUrl + routes[i].PathPattern)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, tt.Status, resp.StatusCode)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+			assert.Contains(t, strings.ReplaceAll(string(body), " ", ""), tt.Code)
+		})
+	}
+}
+
 func TestHttpWithResponseDecorator(t *testing.T) {
 	assert.NotNil(t, alchemy.HttpWithResponseDecorator(func(resp any) any {
 		return map[string]any{"data": resp}
@@ -146,3 +543,53 @@ func TestSendHeader(t *testing.T) {
 		})
 	})
 }
+
+// TraceMiddleware returns a middleware which appends the name to the X-Trace header of the response.
+func TraceMiddleware(name string) alchemy.HttpMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Add("X-Trace", name)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+func TestHttpWithMiddleware(t *testing.T) {
+	baseUrl := ServeHttp(t, []alchemy.RouteDesc{
+		{
+			HttpMethod:  http.MethodGet,
+			PathPattern: "/v1/books",
+			Handler:     HandleFunc(func(ctx context.Context) (any, error) { return &emptypb.Empty{}, nil }),
+		},
+	},
+		alchemy.HttpWithAdditionalHandler(http.MethodGet, "/admin/metrics", http.NotFoundHandler()),
+		alchemy.HttpWithMiddleware(TraceMiddleware("first")),
+		alchemy.HttpWithPathPrefixMiddleware("/admin/", TraceMiddleware("admin")),
+		alchemy.HttpWithMiddleware(TraceMiddleware("last")),
+	)
+
+	cases := []struct {
+		Path  string
+		Trace []string
+	}{
+		{Path: "/v1/books", Trace: []string{"first", "last"}},
+		{Path: "/admin/metrics", Trace: []string{"first", "admin", "last"}},
+		{Path: "/admin", Trace: []string{"first", "admin", "last"}},
+		{Path: "/administrator", Trace: []string{"first", "last"}},
+		{Path: "/not-found", Trace: []string{"first", "last"}},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Path, func(t *testing.T) {
+			resp, err := http.Get(baseUrl + tt.Path)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, tt.Trace, resp.Header.Values("X-Trace"))
+		})
+	}
+}
+
+func TestHttpWithPathPrefixMiddleware(t *testing.T) {
+	assert.NotNil(t, alchemy.HttpWithPathPrefixMiddleware("/admin", TraceMiddleware("admin")))
+}
@@ -0,0 +1,44 @@
+package pattern
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitVerb(t *testing.T) {
+	cases := []struct {
+		Template string
+		Path     string
+		Verb     string
+		Error    bool
+	}{
+		{Template: "v1/books", Path: "v1/books"},
+		{Template: "v1/books:publish", Path: "v1/books", Verb: "publish"},
+		{Template: "v1/{name=books/*}:publish", Path: "v1/{name=books/*}", Verb: "publish"},
+		{Template: "v1/{id:[0-9]+}", Path: "v1/{id:[0-9]+}"},
+		{Template: "v1:beta/books", Path: "v1:beta/books"},
+		{Template: "v1/books:", Error: true},
+		{Template: "v1/{id", Error: true},
+		{Template: "v1/id}", Error: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Template, func(t *testing.T) {
+			path, verb, err := splitVerb(tt.Template)
+			if (tt.Error && assert.Error(t, err)) || assert.NoError(t, err) {
+				assert.Equal(t, tt.Path, path)
+				assert.Equal(t, tt.Verb, verb)
+			}
+		})
+	}
+}
+
+func TestIsFieldPath(t *testing.T) {
+	assert.True(t, isFieldPath("name"))
+	assert.True(t, isFieldPath("book.name_2"))
+	assert.False(t, isFieldPath(""))
+	assert.False(t, isFieldPath("book..name"))
+	assert.False(t, isFieldPath("2name"))
+	assert.False(t, isFieldPath("book-name"))
+}
@@ -0,0 +1,173 @@
+package pattern_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wjiec/alchemy/internal/pattern"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		Pattern string
+		Names   []string
+		Error   bool
+	}{
+		{
+			Pattern: "/api/users/{id}",
+			Names:   []string{"id"},
+		},
+		{
+			Pattern: "/api/users/{id:[0-5]+}",
+			Names:   []string{"id"},
+		},
+		{
+			Pattern: "/api/users/{id:[1-5]{8,}}",
+			Names:   []string{"id"},
+		},
+		{
+			Pattern: "/api/users/{id}/names/{name}",
+			Names:   []string{"id", "name"},
+		},
+		{
+			Pattern: "/api/users",
+			Names:   []string{},
+		},
+		{
+			Pattern: "/v1/{name=projects/*/books/*}",
+			Names:   []string{"name"},
+		},
+		{
+			Pattern: "/v1/{book.name=shelves/*/books/*}:publish",
+			Names:   []string{"book.name"},
+		},
+		{
+			Pattern: "/v1/{parent}/files/{file.path=**}",
+			Names:   []string{"parent", "file.path"},
+		},
+		{
+			Pattern: "/v1/*/users/**:search",
+			Names:   []string{},
+		},
+		{
+			Pattern: "/",
+			Names:   []string{},
+		},
+		{
+			Pattern: "/api/users/{id",
+			Error:   true,
+		},
+		{
+			Pattern: "api/users",
+			Error:   true,
+		},
+		{
+			Pattern: "/v1/{name=projects/{id}}",
+			Error:   true,
+		},
+		{
+			Pattern: "/v1/**/books",
+			Error:   true,
+		},
+		{
+			Pattern: "/v1/books:",
+			Error:   true,
+		},
+		{
+			Pattern: "/v1//books",
+			Error:   true,
+		},
+		{
+			Pattern: "/v1/{1name}",
+			Error:   true,
+		},
+		{
+			Pattern: "/api/users/{id:{}",
+			Error:   true,
+		},
+	}
+
+	for i, tt := range cases {
+		t.Run(fmt.Sprintf("case-%d", i), func(t *testing.T) {
+			names, err := pattern.Parse(tt.Pattern)
+			if (tt.Error && assert.Error(t, err)) || assert.NoError(t, err) {
+				assert.Equal(t, tt.Names, names)
+			}
+		})
+	}
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	cases := []struct {
+		Pattern    string
+		Path       string
+		Parameters []pattern.Parameter
+	}{
+		{
+			Pattern: "/api/users",
+			Path:    "/api/users",
+		},
+		{
+			Pattern:    "/api/users/{id}",
+			Path:       "/api/users/{id}",
+			Parameters: []pattern.Parameter{{Name: "id", Template: "*"}},
+		},
+		{
+			Pattern:    "/api/users/{id:[0-9]+}",
+			Path:       "/api/users/{id}",
+			Parameters: []pattern.Parameter{{Name: "id", Template: "*", Regexp: "[0-9]+"}},
+		},
+		{
+			Pattern:    "/v1/{name=shelves/*/books/*}:publish",
+			Path:       "/v1/{name}:publish",
+			Parameters: []pattern.Parameter{{Name: "name", Template: "shelves/*/books/*"}},
+		},
+		{
+			Pattern:    "/v1/*/{book.name=files/**}",
+			Path:       "/v1/*/{book.name}",
+			Parameters: []pattern.Parameter{{Name: "book.name", Template: "files/**"}},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Pattern, func(t *testing.T) {
+			path, parameters, err := pattern.OpenAPIPath(tt.Pattern)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.Path, path)
+				assert.Equal(t, tt.Parameters, parameters)
+			}
+		})
+	}
+
+	_, _, err := pattern.OpenAPIPath("v1/users")
+	assert.Error(t, err)
+}
+
+func TestTemplate_MuxPattern(t *testing.T) {
+	cases := []struct {
+		Pattern string
+		Mux     string
+		Verb    bool
+	}{
+		{Pattern: "/api/users", Mux: "/api/users"},
+		{Pattern: "/api/users/{id}", Mux: "/api/users/{id}"},
+		{Pattern: "/api/users/{id:[0-9]+}", Mux: "/api/users/{id:[0-9]+}"},
+		{Pattern: "/v1/{name=shelves/*/books/*}", Mux: "/v1/{name:shelves/[^/]+/books/[^/]+}"},
+		{Pattern: "/v1/{name=files/**}", Mux: "/v1/{name:files(?:/.*)?}"},
+		{Pattern: "/v1/*/books/**", Mux: "/v1/{*1:[^/]+}/books/{*3:.*}"},
+		{Pattern: "/v1/{name=books/*}:publish", Mux: "/v1/{name:books/[^/]+}:publish", Verb: true},
+		{Pattern: "/v1/books.v2", Mux: "/v1/books.v2"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Pattern, func(t *testing.T) {
+			template, err := pattern.ParseTemplate(tt.Pattern)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.Mux, template.MuxPattern())
+				assert.Equal(t, tt.Verb, template.HasVerb())
+			}
+		})
+	}
+}
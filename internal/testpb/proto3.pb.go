@@ -7,8 +7,10 @@
 package testpb
 
 import (
+	multipart "github.com/wjiec/alchemy/multipart"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -32,6 +34,8 @@ type Proto3Message struct {
 	DoubleValue    float64                `protobuf:"fixed64,6,opt,name=double_value,json=doubleValue,proto3" json:"double_value,omitempty"`
 	RepeatedString []string               `protobuf:"bytes,7,rep,name=repeated_string,json=repeatedString,proto3" json:"repeated_string,omitempty"`
 	RepeatedInt32  []string               `protobuf:"bytes,8,rep,name=repeated_int32,json=repeatedInt32,proto3" json:"repeated_int32,omitempty"`
+	MultipartValue *multipart.Multipart   `protobuf:"bytes,9,opt,name=multipart_value,json=multipartValue,proto3" json:"multipart_value,omitempty"`
+	UpdateMask     *fieldmaskpb.FieldMask `protobuf:"bytes,10,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -129,11 +133,25 @@ func (x *Proto3Message) GetRepeatedInt32() []string {
 	return nil
 }
 
+func (x *Proto3Message) GetMultipartValue() *multipart.Multipart {
+	if x != nil {
+		return x.MultipartValue
+	}
+	return nil
+}
+
+func (x *Proto3Message) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
 var File_internal_testpb_proto3_proto protoreflect.FileDescriptor
 
 const file_internal_testpb_proto3_proto_rawDesc = "" +
 	"\n" +
-	"\x1cinternal/testpb/proto3.proto\x12\x1dalchemy.codec.internal.testpb\"\xf9\x02\n" +
+	"\x1cinternal/testpb/proto3.proto\x12\x1dalchemy.codec.internal.testpb\x1a google/protobuf/field_mask.proto\x1a\x19multipart/multipart.proto\"\xfd\x03\n" +
 	"\rProto3Message\x12P\n" +
 	"\fnested_value\x18\xe7\a \x01(\v2,.alchemy.codec.internal.testpb.Proto3MessageR\vnestedValue\x12!\n" +
 	"\fstring_value\x18\x01 \x01(\tR\vstringValue\x12\x1f\n" +
@@ -147,7 +165,11 @@ const file_internal_testpb_proto3_proto_rawDesc = "" +
 	"floatValue\x12!\n" +
 	"\fdouble_value\x18\x06 \x01(\x01R\vdoubleValue\x12'\n" +
 	"\x0frepeated_string\x18\a \x03(\tR\x0erepeatedString\x12%\n" +
-	"\x0erepeated_int32\x18\b \x03(\tR\rrepeatedInt32B\xf2\x01\n" +
+	"\x0erepeated_int32\x18\b \x03(\tR\rrepeatedInt32\x12E\n" +
+	"\x0fmultipart_value\x18\t \x01(\v2\x1c.alchemy.multipart.MultipartR\x0emultipartValue\x12;\n" +
+	"\vupdate_mask\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMaskB\xf2\x01\n" +
 	"!com.alchemy.codec.internal.testpbB\vProto3ProtoP\x01Z(github.com/wjiec/alchemy/internal/testpb\xa2\x02\x04ACIT\xaa\x02\x1dAlchemy.Codec.Internal.Testpb\xca\x02\x1dAlchemy\\Codec\\Internal\\Testpb\xe2\x02)Alchemy\\Codec\\Internal\\Testpb\\GPBMetadata\xea\x02 Alchemy::Codec::Internal::Testpbb\x06proto3"
 
 var (
@@ -164,15 +186,19 @@ func file_internal_testpb_proto3_proto_rawDescGZIP() []byte {
 
 var file_internal_testpb_proto3_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
 var file_internal_testpb_proto3_proto_goTypes = []any{
-	(*Proto3Message)(nil), // 0: alchemy.codec.internal.testpb.Proto3Message
+	(*Proto3Message)(nil),         // 0: alchemy.codec.internal.testpb.Proto3Message
+	(*multipart.Multipart)(nil),   // 1: alchemy.multipart.Multipart
+	(*fieldmaskpb.FieldMask)(nil), // 2: google.protobuf.FieldMask
 }
 var file_internal_testpb_proto3_proto_depIdxs = []int32{
 	0, // 0: alchemy.codec.internal.testpb.Proto3Message.nested_value:type_name -> alchemy.codec.internal.testpb.Proto3Message
-	1, // [1:1] is the sub-list for method output_type
-	1, // [1:1] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	1, // 1: alchemy.codec.internal.testpb.Proto3Message.multipart_value:type_name -> alchemy.multipart.Multipart
+	2, // 2: alchemy.codec.internal.testpb.Proto3Message.update_mask:type_name -> google.protobuf.FieldMask
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_internal_testpb_proto3_proto_init() }
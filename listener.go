@@ -0,0 +1,121 @@
+package alchemy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// AddrListener is implemented by the Addr which creates the listener itself
+// instead of listening on its network and address.
+type AddrListener interface {
+	Addr
+
+	// Listen returns the listener the server accepts the connections from.
+	Listen(ctx context.Context) (net.Listener, error)
+}
+
+// listen returns the listener of the Addr.
+func listen(ctx context.Context, addr Addr) (net.Listener, error) {
+	if listener, ok := addr.(AddrListener); ok {
+		return listener.Listen(ctx)
+	}
+	return net.Listen(addr.Network(ctx), addr.String(ctx))
+}
+
+// NewListenerAddr creates an Addr from a listener created in advance, e.g.
+// on "127.0.0.1:0" to read back the port bound in tests.
+//
+// The listener is closed when the server is stopped.
+func NewListenerAddr(l net.Listener) AddrListener {
+	return &listenerAddress{listener: l}
+}
+
+// listenerAddress is an Addr of a listener.
+type listenerAddress struct {
+	listener net.Listener
+}
+
+// Network returns the network of the listener.
+func (a *listenerAddress) Network(_ context.Context) string { return a.listener.Addr().Network() }
+
+// String returns the address of the listener.
+func (a *listenerAddress) String(_ context.Context) string { return a.listener.Addr().String() }
+
+// Listen returns the listener.
+func (a *listenerAddress) Listen(_ context.Context) (net.Listener, error) { return a.listener, nil }
+
+// NewFileAddr creates an Addr from a listening socket inherited as the file
+// descriptor fd, e.g. from the parent process.
+func NewFileAddr(fd uintptr) AddrListener {
+	return &fileAddress{fd: fd, name: "fd" + strconv.FormatUint(uint64(fd), 10)}
+}
+
+// fileAddress is an Addr of a listening socket file descriptor.
+type fileAddress struct {
+	fd   uintptr
+	name string
+}
+
+// Network returns "fd" since the network is unknown until the socket is used.
+func (a *fileAddress) Network(_ context.Context) string { return "fd" }
+
+// String returns the name of the file descriptor.
+func (a *fileAddress) String(_ context.Context) string { return a.name }
+
+// Listen returns a listener of the file descriptor.
+func (a *fileAddress) Listen(_ context.Context) (net.Listener, error) {
+	f := os.NewFile(a.fd, a.name)
+	if f == nil {
+		return nil, fmt.Errorf("alchemy: invalid file descriptor %d", a.fd)
+	}
+	// The net.FileListener duplicates the file descriptor.
+	defer func() { _ = f.Close() }()
+
+	return net.FileListener(f)
+}
+
+// systemdListenFdsStart is the first file descriptor passed by systemd.
+const systemdListenFdsStart = 3
+
+// NewSystemdAddr creates an Addr from a socket passed by the systemd socket
+// activation, the socket is selected by the name configured by FileDescriptorName
+// of the socket unit, an empty name selects the first socket.
+//
+// See https://www.freedesktop.org/software/systemd/man/latest/sd_listen_fds.html
+func NewSystemdAddr(name string) AddrListener {
+	return &systemdAddress{name: name}
+}
+
+// systemdAddress is an Addr of a socket passed by systemd.
+type systemdAddress struct {
+	name string
+}
+
+// Network returns "systemd".
+func (a *systemdAddress) Network(_ context.Context) string { return "systemd" }
+
+// String returns the name of the socket.
+func (a *systemdAddress) String(_ context.Context) string { return a.name }
+
+// Listen returns a listener of the socket passed by systemd.
+func (a *systemdAddress) Listen(ctx context.Context) (net.Listener, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, fmt.Errorf("alchemy: no socket passed by systemd")
+	}
+	fds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || fds <= 0 {
+		return nil, fmt.Errorf("alchemy: no socket passed by systemd")
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for i := 0; i < fds; i++ {
+		if len(a.name) == 0 || (i < len(names) && names[i] == a.name) {
+			return NewFileAddr(uintptr(systemdListenFdsStart + i)).Listen(ctx)
+		}
+	}
+	return nil, fmt.Errorf("alchemy: no socket named %q passed by systemd", a.name)
+}
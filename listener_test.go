@@ -0,0 +1,90 @@
+package alchemy_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wjiec/alchemy"
+)
+
+// ServeAddr serves a pong handler on the addr until the test finishes.
+func ServeAddr(t *testing.T, addr alchemy.Addr) {
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(addr,
+			alchemy.HttpWithAdditionalHandler(http.MethodGet, "/ping", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				_, _ = w.Write([]byte("pong"))
+			})),
+		),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Start(ctx) }()
+	t.Cleanup(func() { cancel(); assert.NoError(t, <-done) })
+
+	require.NoError(t, app.WaitReady(ctx))
+}
+
+// Ping requests the pong handler at the address.
+func Ping(t *testing.T, addr string) {
+	resp, err := http.Get("http://" + addr + "/ping")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "pong", string(body))
+}
+
+func TestNewListenerAddr(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	addr := alchemy.NewListenerAddr(l)
+	assert.Equal(t, "tcp", addr.Network(context.Background()))
+	assert.Equal(t, l.Addr().String(), addr.String(context.Background()))
+
+	ServeAddr(t, addr)
+	Ping(t, l.Addr().String())
+}
+
+func TestNewFileAddr(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file listeners are not supported on windows")
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	f, err := l.(*net.TCPListener).File()
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	ServeAddr(t, alchemy.NewFileAddr(f.Fd()))
+	Ping(t, l.Addr().String())
+}
+
+func TestNewSystemdAddr(t *testing.T) {
+	_, err := alchemy.NewSystemdAddr("").Listen(context.Background())
+	assert.ErrorContains(t, err, "no socket passed by systemd")
+
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+	_, err = alchemy.NewSystemdAddr("").Listen(context.Background())
+	assert.ErrorContains(t, err, "no socket passed by systemd")
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDNAMES", "http")
+	_, err = alchemy.NewSystemdAddr("grpc").Listen(context.Background())
+	assert.ErrorContains(t, err, `no socket named "grpc" passed by systemd`)
+}
@@ -1,29 +1,183 @@
+// Package multipart references the files uploaded with the multipart requests
+// from the request messages.
+//
+// The files are registered to a request-scoped [Uploads] and referenced by
+// opaque identifiers instead of the pointers smuggled in the removed ptr field
+// of the earlier versions. GetUploadsFromMultipartContext resolves them against
+// the registry of the request carried by the context of the handler, and reports
+// the unknown references as an error:
+//
+//	files, err := multipart.GetUploadsFromMultipartContext(ctx, in.GetAvatar())
+//	if err != nil {
+//		return nil, status.Error(codes.InvalidArgument, err.Error())
+//	}
+//
+// GetUploadsFromMultipart and NewMultipartFromUploads keep their signatures of
+// the earlier versions, so the existing handlers still compile and work. The
+// clients are not affected, the files may be uploaded with either the "<field>"
+// or the "<field>.ptr" form keys.
 package multipart
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"mime/multipart"
-	"unsafe"
+	"runtime"
+	"sync"
+	"weak"
 )
 
 // FileHeader is an alias for the multipart.FileHeader type,
 // which represents the parsed File part of a multipart message.
 type FileHeader = multipart.FileHeader
 
-// GetUploadsFromMultipart extracts file headers from a Multipart message.
-func GetUploadsFromMultipart(multipart *Multipart) []*FileHeader {
-	res := make([]*FileHeader, len(multipart.Ptr))
-	for i, ptr := range multipart.Ptr {
-		//goland:noinspection GoVetUnsafePointer
-		res[i] = (*FileHeader)(unsafe.Pointer(uintptr(ptr)))
+var (
+	// ErrNoUploads is returned when there is no upload registry in the context.
+	ErrNoUploads = errors.New("multipart: no upload registry in context")
+
+	// ErrUnknownUpload is returned when a Multipart message references a file
+	// that was not uploaded with the current request.
+	ErrUnknownUpload = errors.New("multipart: unknown upload")
+)
+
+// Uploads is a request-scoped registry of the uploaded files, keyed by opaque identifiers.
+//
+// The identifiers are random and only meaningful for the registry which issued them,
+// so a client cannot reference a file that was not uploaded with its own request.
+type Uploads struct {
+	mu    sync.RWMutex
+	files map[string]*FileHeader
+}
+
+// NewUploads creates and returns an empty upload registry.
+func NewUploads() *Uploads {
+	return &Uploads{}
+}
+
+// Add registers the file to the registry and returns its opaque identifier.
+func (u *Uploads) Add(file *FileHeader) string {
+	id := register(file)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.files == nil {
+		u.files = make(map[string]*FileHeader)
+	}
+	u.files[id] = file
+
+	return id
+}
+
+// Get returns the file registered with the identifier.
+func (u *Uploads) Get(id string) (*FileHeader, bool) {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	file, found := u.files[id]
+	return file, found
+}
+
+// index resolves the identifiers issued by all the registries for the functions
+// without a context, the files are referenced weakly and their identifiers are
+// removed once they are garbage collected, i.e. after their requests end.
+var index sync.Map // string -> weak.Pointer[FileHeader]
+
+// register adds the file to the index and returns its new opaque identifier.
+func register(file *FileHeader) string {
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+	id := hex.EncodeToString(buf[:])
+
+	index.Store(id, weak.Make(file))
+	runtime.AddCleanup(file, func(id string) { index.Delete(id) }, id)
+	return id
+}
+
+// uploadsKey is how we find the [*Uploads] in a context.Context.
+type uploadsKey struct{}
+
+// NewContextWithUploads returns a new Context, derived from ctx, which carries
+// the provided [*Uploads].
+func NewContextWithUploads(ctx context.Context, uploads *Uploads) context.Context {
+	return context.WithValue(ctx, uploadsKey{}, uploads)
+}
+
+// UploadsFromContext returns a [*Uploads] from ctx.
+func UploadsFromContext(ctx context.Context) (*Uploads, bool) {
+	if raw := ctx.Value(uploadsKey{}); raw != nil {
+		return raw.(*Uploads), true
+	}
+	return nil, false
+}
+
+// GetUploadsFromMultipart returns the files referenced by a Multipart message,
+// the references which are unknown or whose files are released are skipped.
+//
+// Unlike GetUploadsFromMultipartContext, the references are resolved against the
+// files uploaded with any of the requests in flight, since there is no context.
+func GetUploadsFromMultipart(mp *Multipart) []*FileHeader {
+	var res []*FileHeader
+	for _, id := range mp.GetIds() {
+		if ref, found := index.Load(id); found {
+			if file := ref.(weak.Pointer[FileHeader]).Value(); file != nil {
+				res = append(res, file)
+			}
+		}
 	}
 	return res
 }
 
-// NewMultipartFromUploads creates a Multipart message from a slice of FileHeader pointers.
-func NewMultipartFromUploads(files []*multipart.FileHeader) *Multipart {
+// NewMultipartFromUploads creates a Multipart message referencing the files,
+// which can be resolved by GetUploadsFromMultipart as long as the files are
+// referenced by the caller.
+//
+// Unlike NewMultipartFromUploadsContext, the files are not registered to the
+// upload registry of a request.
+func NewMultipartFromUploads(files []*FileHeader) *Multipart {
 	var res Multipart
 	for _, file := range files {
-		res.Ptr = append(res.Ptr, uint64(uintptr(unsafe.Pointer(file))))
+		res.Ids = append(res.Ids, register(file))
 	}
 	return &res
 }
+
+// GetUploadsFromMultipartContext resolves the files referenced by a Multipart
+// message against the upload registry carried by ctx.
+//
+// It returns ErrUnknownUpload if any of the identifiers was not issued by the
+// registry of the current request.
+func GetUploadsFromMultipartContext(ctx context.Context, mp *Multipart) ([]*FileHeader, error) {
+	if len(mp.GetIds()) == 0 {
+		return nil, nil
+	}
+
+	uploads, found := UploadsFromContext(ctx)
+	if !found {
+		return nil, ErrNoUploads
+	}
+
+	res := make([]*FileHeader, len(mp.GetIds()))
+	for i, id := range mp.GetIds() {
+		if res[i], found = uploads.Get(id); !found {
+			return nil, ErrUnknownUpload
+		}
+	}
+	return res, nil
+}
+
+// NewMultipartFromUploadsContext registers the files to the upload registry
+// carried by ctx and returns a Multipart message referencing them.
+func NewMultipartFromUploadsContext(ctx context.Context, files []*FileHeader) (*Multipart, error) {
+	uploads, found := UploadsFromContext(ctx)
+	if !found {
+		return nil, ErrNoUploads
+	}
+
+	var res Multipart
+	for _, file := range files {
+		res.Ids = append(res.Ids, uploads.Add(file))
+	}
+	return &res, nil
+}
@@ -25,8 +25,9 @@ const (
 )
 
 type Multipart struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Ptr           []uint64               `protobuf:"varint,1,rep,packed,name=ptr,proto3" json:"ptr,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// opaque identifiers of the uploaded files in the request-scoped upload registry
+	Ids           []string `protobuf:"bytes,2,rep,name=ids,proto3" json:"ids,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -61,9 +62,9 @@ func (*Multipart) Descriptor() ([]byte, []int) {
 	return file_multipart_multipart_proto_rawDescGZIP(), []int{0}
 }
 
-func (x *Multipart) GetPtr() []uint64 {
+func (x *Multipart) GetIds() []string {
 	if x != nil {
-		return x.Ptr
+		return x.Ids
 	}
 	return nil
 }
@@ -72,9 +73,9 @@ var File_multipart_multipart_proto protoreflect.FileDescriptor
 
 const file_multipart_multipart_proto_rawDesc = "" +
 	"\n" +
-	"\x19multipart/multipart.proto\x12\x11alchemy.multipart\"\x1d\n" +
+	"\x19multipart/multipart.proto\x12\x11alchemy.multipart\"(\n" +
 	"\tMultipart\x12\x10\n" +
-	"\x03ptr\x18\x01 \x03(\x04R\x03ptrB\xb0\x01\n" +
+	"\x03ids\x18\x02 \x03(\tR\x03idsJ\x04\b\x01\x10\x02R\x03ptrB\xb0\x01\n" +
 	"\x15com.alchemy.multipartB\x0eMultipartProtoP\x01Z\"github.com/wjiec/alchemy/multipart\xa2\x02\x03AMX\xaa\x02\x11Alchemy.Multipart\xca\x02\x11Alchemy\\Multipart\xe2\x02\x1dAlchemy\\Multipart\\GPBMetadata\xea\x02\x12Alchemy::Multipartb\x06proto3"
 
 var (
@@ -2,6 +2,7 @@ package multipart
 
 import (
 	"bytes"
+	"context"
 	"mime/multipart"
 	"testing"
 
@@ -18,9 +19,6 @@ func NewFormFile(mw *multipart.Writer, key, filename string, data []byte) error
 	if _, err = w.Write(data); err != nil {
 		return err
 	}
-	if err = mw.Close(); err != nil {
-		return err
-	}
 
 	return nil
 }
@@ -46,14 +44,63 @@ func NewFormData() (*multipart.Form, error) {
 	return r.ReadForm(1 << 20)
 }
 
+func TestGetUploadsFromMultipartContext(t *testing.T) {
+	form, err := NewFormData()
+	require.NoError(t, err)
+
+	t.Run("registered", func(t *testing.T) {
+		ctx := NewContextWithUploads(context.Background(), NewUploads())
+		for _, files := range form.File {
+			mp, err := NewMultipartFromUploadsContext(ctx, files)
+			if assert.NoError(t, err) && assert.NotNil(t, mp) {
+				uploads, err := GetUploadsFromMultipartContext(ctx, mp)
+				if assert.NoError(t, err) {
+					assert.Equal(t, files, uploads)
+				}
+			}
+		}
+	})
+
+	t.Run("other request", func(t *testing.T) {
+		ctx := NewContextWithUploads(context.Background(), NewUploads())
+		mp, err := NewMultipartFromUploadsContext(ctx, form.File["foo"])
+		require.NoError(t, err)
+
+		other := NewContextWithUploads(context.Background(), NewUploads())
+		_, err = GetUploadsFromMultipartContext(other, mp)
+		assert.ErrorIs(t, err, ErrUnknownUpload)
+	})
+
+	t.Run("forged", func(t *testing.T) {
+		ctx := NewContextWithUploads(context.Background(), NewUploads())
+		_, err := GetUploadsFromMultipartContext(ctx, &Multipart{Ids: []string{"824634330112"}})
+		assert.ErrorIs(t, err, ErrUnknownUpload)
+	})
+
+	t.Run("no registry", func(t *testing.T) {
+		_, err := NewMultipartFromUploadsContext(context.Background(), form.File["bar"])
+		assert.ErrorIs(t, err, ErrNoUploads)
+	})
+}
+
 func TestGetUploadsFromMultipart(t *testing.T) {
 	form, err := NewFormData()
 	require.NoError(t, err)
 
-	for _, files := range form.File {
-		mp := NewMultipartFromUploads(files)
-		if assert.NotNil(t, mp) {
-			assert.Equal(t, files, GetUploadsFromMultipart(mp))
+	t.Run("registered", func(t *testing.T) {
+		for _, files := range form.File {
+			assert.Equal(t, files, GetUploadsFromMultipart(NewMultipartFromUploads(files)))
 		}
-	}
+	})
+
+	t.Run("request", func(t *testing.T) {
+		ctx := NewContextWithUploads(context.Background(), NewUploads())
+		mp, err := NewMultipartFromUploadsContext(ctx, form.File["foo"])
+		require.NoError(t, err)
+		assert.Equal(t, form.File["foo"], GetUploadsFromMultipart(mp))
+	})
+
+	t.Run("forged", func(t *testing.T) {
+		assert.Empty(t, GetUploadsFromMultipart(&Multipart{Ids: []string{"824634330112"}}))
+	})
 }
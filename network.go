@@ -2,6 +2,10 @@ package alchemy
 
 import (
 	"context"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
 )
 
 // Addr represents a network end point address.
@@ -31,3 +35,62 @@ func (a *networkAddress) String(_ context.Context) string { return a.address }
 
 // TCP creates a net.Addr implementation with the network set to "tcp" and the specified address.
 func TCP(address string) Addr { return &networkAddress{network: "tcp", address: address} }
+
+// NewFlagAddr creates an Addr whose address is configured by the flag of the given
+// name on the root command, the flag defaults to defaultAddress.
+//
+// The flag can also be set from the configuration file and the environment
+// variables loaded by WithConfig.
+func NewFlagAddr(flag, network, defaultAddress string) Addr {
+	return &flagAddress{flag: flag, networkAddress: networkAddress{network: network, address: defaultAddress}}
+}
+
+// FlagTCP creates an Addr with the network set to "tcp" and the address configured
+// by the flag of the given name on the root command.
+func FlagTCP(flag, defaultAddress string) Addr { return NewFlagAddr(flag, "tcp", defaultAddress) }
+
+// flagAddress is a networkAddress whose address is bound to a flag.
+type flagAddress struct {
+	flag string
+	networkAddress
+}
+
+// bindFlags registers the flag of the address.
+func (a *flagAddress) bindFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&a.address, a.flag, a.address, "The address to listen on")
+}
+
+// flagBinder is implemented by the Addr which is configured by flags.
+type flagBinder interface {
+	bindFlags(fs *pflag.FlagSet)
+}
+
+// NewEnvAddr creates an Addr whose address is taken from the environment variable
+// of the given name, e.g. "PORT", and defaults to defaultAddress if the variable is
+// not set. A value consisting of digits only is treated as a port, e.g. "8080"
+// is treated as ":8080".
+func NewEnvAddr(env, network, defaultAddress string) Addr {
+	return &envAddress{env: env, networkAddress: networkAddress{network: network, address: defaultAddress}}
+}
+
+// EnvTCP creates an Addr with the network set to "tcp" and the address taken from
+// the environment variable of the given name.
+func EnvTCP(env, defaultAddress string) Addr { return NewEnvAddr(env, "tcp", defaultAddress) }
+
+// envAddress is a networkAddress whose address is taken from an environment variable.
+type envAddress struct {
+	env string
+	networkAddress
+}
+
+// String returns the address from the environment variable or the default address.
+func (a *envAddress) String(_ context.Context) string {
+	value, found := os.LookupEnv(a.env)
+	if !found || len(value) == 0 {
+		return a.address
+	}
+	if strings.Trim(value, "0123456789") == "" {
+		return ":" + value
+	}
+	return value
+}
@@ -1,6 +1,7 @@
 package alchemy_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -15,3 +16,21 @@ func TestNewAddr(t *testing.T) {
 func TestTCP(t *testing.T) {
 	assert.NotNil(t, alchemy.TCP(":8080"))
 }
+
+func TestFlagTCP(t *testing.T) {
+	addr := alchemy.FlagTCP("http-addr", ":8080")
+	assert.Equal(t, "tcp", addr.Network(context.Background()))
+	assert.Equal(t, ":8080", addr.String(context.Background()))
+}
+
+func TestEnvTCP(t *testing.T) {
+	addr := alchemy.EnvTCP("ALCHEMY_TEST_PORT", ":8080")
+	assert.Equal(t, "tcp", addr.Network(context.Background()))
+	assert.Equal(t, ":8080", addr.String(context.Background()))
+
+	t.Setenv("ALCHEMY_TEST_PORT", "9090")
+	assert.Equal(t, ":9090", addr.String(context.Background()))
+
+	t.Setenv("ALCHEMY_TEST_PORT", "127.0.0.1:9090")
+	assert.Equal(t, "127.0.0.1:9090", addr.String(context.Background()))
+}
@@ -0,0 +1,199 @@
+package alchemy
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// HttpWithOpenAPI returns an HttpOption that serves the OpenAPI document of the
+// registered services at the given path, e.g. "/openapi.json".
+//
+// The documents generated by protoc-gen-alchemy with the "openapi" option for
+// each service are merged into a single document titled with the App's name.
+func HttpWithOpenAPI(pattern string) HttpOption {
+	return func(hs *httpServer) error {
+		document := sync.OnceValues(func() ([]byte, error) {
+			return mergeOpenAPIDocuments(hs.name, hs.openapiDocs)
+		})
+
+		return HttpWithAdditionalHandler(http.MethodGet, pattern, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			data, err := document()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(data)
+		}))(hs)
+	}
+}
+
+// HttpWithOpenAPIUI returns an HttpOption that serves a Redoc page rendering the
+// OpenAPI document at documentURL, which is usually served by HttpWithOpenAPI.
+//
+// The page loads the Redoc bundle of version DefaultRedocVersion from its CDN by
+// default, the bundle can be served from the binary with OpenAPIUIWithBundle, so
+// that the page works offline, or loaded from another source verified by its
+// integrity hash with OpenAPIUIWithScript.
+func HttpWithOpenAPIUI(pattern, documentURL string, options ...OpenAPIUIOption) HttpOption {
+	ui := &openapiUI{script: "https://cdn.jsdelivr.net/npm/redoc@" + DefaultRedocVersion + "/bundles/redoc.standalone.js"}
+	for _, applyOption := range options {
+		applyOption(ui)
+	}
+
+	return func(hs *httpServer) error {
+		if ui.bundle != nil {
+			ui.script = strings.TrimSuffix(pattern, "/") + "/redoc.standalone.js"
+			err := HttpWithAdditionalHandler(http.MethodGet, ui.script, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+				_, _ = w.Write(ui.bundle)
+			}))(hs)
+			if err != nil {
+				return err
+			}
+		}
+
+		return HttpWithAdditionalHandler(http.MethodGet, pattern, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_ = openapiUITemplate.Execute(w, map[string]string{
+				"Title":       hs.name,
+				"DocumentURL": documentURL,
+				"Script":      ui.script,
+				"Integrity":   ui.integrity,
+			})
+		}))(hs)
+	}
+}
+
+// DefaultRedocVersion is the version of the Redoc bundle loaded by the OpenAPI UI by default.
+const DefaultRedocVersion = "2.1.5"
+
+// openapiUI represents the configuration of the page rendering an OpenAPI document.
+type openapiUI struct {
+	script    string
+	integrity string
+	bundle    []byte
+}
+
+// OpenAPIUIOption used to configure the page served by HttpWithOpenAPIUI.
+type OpenAPIUIOption func(ui *openapiUI)
+
+// OpenAPIUIWithBundle serves the Redoc standalone bundle along with the page, e.g.
+// a redoc.standalone.js embedded into the binary, so that the page neither depends
+// on the CDN nor on the network.
+//
+// The integrity hash of the bundle is derived from its content.
+func OpenAPIUIWithBundle(bundle []byte) OpenAPIUIOption {
+	return func(ui *openapiUI) {
+		sum := sha512.Sum384(bundle)
+		ui.bundle = bundle
+		ui.integrity = "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+	}
+}
+
+// OpenAPIUIWithScript loads the Redoc standalone bundle from src, which is verified
+// by the browser against the subresource integrity hash, e.g. "sha384-...".
+func OpenAPIUIWithScript(src, integrity string) OpenAPIUIOption {
+	return func(ui *openapiUI) {
+		ui.script = src
+		ui.integrity = integrity
+	}
+}
+
+// openapiUITemplate is the page rendering an OpenAPI document with Redoc.
+var openapiUITemplate = template.Must(template.New("openapi").Parse(`<!DOCTYPE html>
+<html>
+<head>
+  <title>{{ .Title }}</title>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+  <redoc spec-url="{{ .DocumentURL }}"></redoc>
+  <script src="{{ .Script }}"{{ with .Integrity }} integrity="{{ . }}" crossorigin="anonymous"{{ end }}></script>
+</body>
+</html>
+`))
+
+// openapiDocument is the part of an OpenAPI document merged by mergeOpenAPIDocuments.
+type openapiDocument struct {
+	OpenAPI    string                                `json:"openapi"`
+	Info       map[string]any                        `json:"info"`
+	Tags       []any                                 `json:"tags,omitempty"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]json.RawMessage `json:"schemas,omitempty"`
+	} `json:"components"`
+}
+
+// mergeOpenAPIDocuments merges the paths, tags and schemas of the documents into
+// a single document with the given title.
+//
+// The operations and schemas of the earlier documents take precedence, the
+// different operations and schemas of the later documents with the same paths
+// and names are logged as warnings. The tags are deduplicated by their names.
+func mergeOpenAPIDocuments(title string, docs [][]byte) ([]byte, error) {
+	res := openapiDocument{
+		OpenAPI: "3.1.0",
+		Info:    map[string]any{"title": title, "version": "0.0.0"},
+		Paths:   make(map[string]map[string]json.RawMessage),
+	}
+	res.Components.Schemas = make(map[string]json.RawMessage)
+
+	tags := make(map[string]bool)
+	for i, data := range docs {
+		var doc openapiDocument
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+
+		if version, found := doc.Info["version"]; found && i == 0 {
+			res.Info["version"] = version
+		}
+		for _, tag := range doc.Tags {
+			object, _ := tag.(map[string]any)
+			if name, _ := object["name"].(string); !tags[name] || len(name) == 0 {
+				tags[name] = true
+				res.Tags = append(res.Tags, tag)
+			}
+		}
+		for path, item := range doc.Paths {
+			if _, found := res.Paths[path]; !found {
+				res.Paths[path] = make(map[string]json.RawMessage)
+			}
+			for method, operation := range item {
+				if merged, found := res.Paths[path][method]; !found {
+					res.Paths[path][method] = operation
+				} else if !jsonEqual(merged, operation) {
+					slog.Warn("Detected a conflicting OpenAPI operation", "method", method, "path", path)
+				}
+			}
+		}
+		for name, schema := range doc.Components.Schemas {
+			if merged, found := res.Components.Schemas[name]; !found {
+				res.Components.Schemas[name] = schema
+			} else if !jsonEqual(merged, schema) {
+				slog.Warn("Detected a conflicting OpenAPI schema", "name", name)
+			}
+		}
+	}
+
+	return json.Marshal(res)
+}
+
+// jsonEqual reports whether the JSON values are semantically equal.
+func jsonEqual(a, b json.RawMessage) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,147 @@
+package alchemy_test
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"html"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wjiec/alchemy"
+)
+
+func TestHttpWithOpenAPI(t *testing.T) {
+	baseUrl := ServeHttpServices(t, []*alchemy.ServiceDesc{{
+		OpenAPI: []byte(`{
+			"openapi": "3.1.0",
+			"info": {"title": "library.v1.LibraryService", "version": "v1"},
+			"tags": [{"name": "library.v1.LibraryService"}],
+			"paths": {"/v1/books": {"get": {"operationId": "LibraryService_ListBooks"}}},
+			"components": {"schemas": {"library.v1.Book": {"type": "object"}}}
+		}`),
+	}}, alchemy.HttpWithOpenAPI("/openapi.json"), alchemy.HttpWithOpenAPIUI("/docs", "/openapi.json"))
+
+	resp, err := http.Get(baseUrl + "/openapi.json")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{
+		"openapi": "3.1.0",
+		"info": {"title": "TestHttpWithOpenAPI", "version": "v1"},
+		"tags": [{"name": "library.v1.LibraryService"}],
+		"paths": {"/v1/books": {"get": {"operationId": "LibraryService_ListBooks"}}},
+		"components": {"schemas": {"library.v1.Book": {"type": "object"}}}
+	}`, string(body))
+
+	resp, err = http.Get(baseUrl + "/docs")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ = io.ReadAll(resp.Body)
+	assert.Contains(t, string(body), `<redoc spec-url="/openapi.json"></redoc>`)
+	assert.Contains(t, string(body), `/npm/redoc@`+alchemy.DefaultRedocVersion+`/bundles/redoc.standalone.js`)
+}
+
+func TestHttpWithOpenAPIUI(t *testing.T) {
+	bundle := []byte(`console.log("redoc")`)
+	sum := sha512.Sum384(bundle)
+	integrity := "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+
+	baseUrl := ServeHttp(t, nil,
+		alchemy.HttpWithOpenAPIUI("/docs", "/openapi.json", alchemy.OpenAPIUIWithBundle(bundle)),
+		alchemy.HttpWithOpenAPIUI("/cdn/docs", "/openapi.json", alchemy.OpenAPIUIWithScript("https://cdn.example.com/redoc.js", "sha384-abc")),
+	)
+
+	t.Run("bundle", func(t *testing.T) {
+		resp, err := http.Get(baseUrl + "/docs")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, html.UnescapeString(string(body)),
+			`<script src="/docs/redoc.standalone.js" integrity="`+integrity+`" crossorigin="anonymous"></script>`)
+
+		resp, err = http.Get(baseUrl + "/docs/redoc.standalone.js")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		body, _ = io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/javascript; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Equal(t, bundle, body)
+	})
+
+	t.Run("script", func(t *testing.T) {
+		resp, err := http.Get(baseUrl + "/cdn/docs")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body),
+			`<script src="https://cdn.example.com/redoc.js" integrity="sha384-abc" crossorigin="anonymous"></script>`)
+	})
+}
+
+func TestHttpWithOpenAPI_Merge(t *testing.T) {
+	warnings := CaptureWarnings(t)
+	baseUrl := ServeHttpServices(t, []*alchemy.ServiceDesc{
+		{
+			OpenAPI: []byte(`{
+				"openapi": "3.1.0",
+				"info": {"title": "library.v1.LibraryService", "version": "v1"},
+				"tags": [{"name": "library.v1.LibraryService"}, {"name": "shared"}],
+				"paths": {"/v1/books": {"get": {"operationId": "LibraryService_ListBooks"}}},
+				"components": {"schemas": {"google.rpc.Status": {"type": "object"}, "google.protobuf.Empty": {"type": "object"}}}
+			}`),
+		},
+		{
+			OpenAPI: []byte(`{
+				"openapi": "3.1.0",
+				"info": {"title": "library.v1.AuthorService", "version": "v2"},
+				"tags": [{"name": "library.v1.AuthorService"}, {"name": "shared", "description": "duplicated"}],
+				"paths": {
+					"/v1/books": {"get": {"operationId": "AuthorService_ListBooks"}, "post": {"operationId": "AuthorService_CreateBook"}},
+					"/v1/authors": {"get": {"operationId": "AuthorService_ListAuthors"}}
+				},
+				"components": {"schemas": {
+					"google.rpc.Status": {"type": "string"},
+					"google.protobuf.Empty": {"type":"object"},
+					"library.v1.Author": {"type": "object"}
+				}}
+			}`),
+		},
+	}, alchemy.HttpWithOpenAPI("/openapi.json"))
+
+	resp, err := http.Get(baseUrl + "/openapi.json")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, `{
+		"openapi": "3.1.0",
+		"info": {"title": "TestHttpWithOpenAPI_Merge", "version": "v1"},
+		"tags": [{"name": "library.v1.LibraryService"}, {"name": "shared"}, {"name": "library.v1.AuthorService"}],
+		"paths": {
+			"/v1/books": {"get": {"operationId": "LibraryService_ListBooks"}, "post": {"operationId": "AuthorService_CreateBook"}},
+			"/v1/authors": {"get": {"operationId": "AuthorService_ListAuthors"}}
+		},
+		"components": {"schemas": {
+			"google.rpc.Status": {"type": "object"},
+			"google.protobuf.Empty": {"type": "object"},
+			"library.v1.Author": {"type": "object"}
+		}}
+	}`, string(body))
+
+	assert.Contains(t, warnings.String(), `msg="Detected a conflicting OpenAPI operation" method=get path=/v1/books`)
+	assert.Contains(t, warnings.String(), `msg="Detected a conflicting OpenAPI schema" name=google.rpc.Status`)
+	assert.NotContains(t, warnings.String(), "google.protobuf.Empty")
+}
@@ -0,0 +1,77 @@
+package alchemy
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// ErrAppStopped is returned by WaitReady if the App stopped before being ready.
+var ErrAppStopped = errors.New("alchemy: app stopped")
+
+// Ready returns a channel which is closed once the components are started
+// and all the servers are listening.
+func (a *App) Ready() <-chan struct{} {
+	return a.ready
+}
+
+// WaitReady waits until the components are started and all the servers are
+// listening, or the App stops, or the context is done.
+//
+// If the App stops before being ready, the error of the App is returned, or
+// ErrAppStopped if the App stopped without error.
+func (a *App) WaitReady(ctx context.Context) error {
+	select {
+	case <-a.ready:
+		return nil
+	case <-a.done:
+		select {
+		case <-a.ready:
+			return nil
+		default:
+		}
+		if a.serveErr != nil {
+			return a.serveErr
+		}
+		return ErrAppStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// HttpAddr returns the address the HTTP server is listening on, e.g. the port
+// bound for TCP(":0"). It returns nil if the HTTP server is not listening.
+func (a *App) HttpAddr() net.Addr {
+	if a.httpServer == nil {
+		return nil
+	}
+	if l := a.httpServer.Listener(); l != nil {
+		return l.Addr()
+	}
+	return nil
+}
+
+// GrpcAddr returns the address the gRPC server is listening on, e.g. the port
+// bound for TCP(":0"). It returns nil if the gRPC server is not listening.
+func (a *App) GrpcAddr() net.Addr {
+	if a.grpcServer == nil {
+		return nil
+	}
+	if l := a.grpcServer.Listener(); l != nil {
+		return l.Addr()
+	}
+	return nil
+}
+
+// awaitReady closes the ready channel once all the servers are listening,
+// unless the context is done before.
+func (a *App) awaitReady(ctx context.Context, servers []server) {
+	for _, s := range servers {
+		select {
+		case <-s.Ready():
+		case <-ctx.Done():
+			return
+		}
+	}
+	close(a.ready)
+}
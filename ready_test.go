@@ -0,0 +1,69 @@
+package alchemy_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wjiec/alchemy"
+)
+
+func TestApp_WaitReady(t *testing.T) {
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0")),
+		alchemy.WithGrpcServer(alchemy.TCP("127.0.0.1:0")),
+	)
+	require.NoError(t, err)
+	assert.Nil(t, app.HttpAddr())
+	assert.Nil(t, app.GrpcAddr())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Start(ctx) }()
+	defer func() { cancel(); assert.NoError(t, <-done) }()
+
+	require.NoError(t, app.WaitReady(ctx))
+	select {
+	case <-app.Ready():
+	default:
+		assert.Fail(t, "ready channel not closed")
+	}
+
+	for _, addr := range []net.Addr{app.HttpAddr(), app.GrpcAddr()} {
+		if assert.NotNil(t, addr) {
+			assert.NotEqual(t, 0, addr.(*net.TCPAddr).Port)
+
+			conn, err := net.Dial(addr.Network(), addr.String())
+			if assert.NoError(t, err) {
+				_ = conn.Close()
+			}
+		}
+	}
+	assert.NotEqual(t, app.HttpAddr().String(), app.GrpcAddr().String())
+}
+
+func TestApp_WaitReady_Stopped(t *testing.T) {
+	errConnect := errors.New("connection refused")
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0")),
+		alchemy.WithComponent("database", alchemy.NewComponent(func(ctx context.Context) error {
+			return errConnect
+		}, nil)),
+	)
+	require.NoError(t, err)
+
+	go func() { _ = app.Start(context.Background()) }()
+	assert.ErrorIs(t, app.WaitReady(context.Background()), errConnect)
+
+	app, err = alchemy.New(t.Name(), alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0")))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, app.WaitReady(ctx), context.DeadlineExceeded)
+}
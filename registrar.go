@@ -3,7 +3,6 @@ package alchemy
 import (
 	"context"
 
-	"github.com/gorilla/mux"
 	"google.golang.org/grpc"
 )
 
@@ -35,12 +34,12 @@ func (a *App) RegisterService(desc *ServiceDesc, srv any) {
 	}
 
 	if a.httpServer != nil {
-		a.httpServer.services = append(a.httpServer.services, func(r *mux.Router) {
-			for i := range desc.Routes {
-				route := &desc.Routes[i]
-				r.Handle(route.PathPattern, a.httpServer.wrapHttpHandler(route, srv)).Methods(route.HttpMethod)
-			}
-		})
+		if len(desc.OpenAPI) != 0 {
+			a.httpServer.openapiDocs = append(a.httpServer.openapiDocs, desc.OpenAPI)
+		}
+		for i := range desc.Routes {
+			a.httpServer.routes = append(a.httpServer.routes, httpRoute{desc: &desc.Routes[i], srv: srv})
+		}
 	}
 }
 
@@ -49,12 +48,14 @@ func (a *App) RegisterService(desc *ServiceDesc, srv any) {
 type ServiceDesc struct {
 	GrpcServiceDesc *grpc.ServiceDesc // the underlying gRPC service descriptor information
 	Routes          []RouteDesc       // the HTTP route descriptions that map to this service's methods
+	OpenAPI         []byte            // the OpenAPI document of the service's routes, if generated
 }
 
 // RouteDesc defines an HTTP route mapping for a gRPC method.
 type RouteDesc struct {
+	FullMethod     string             // the full name of the gRPC method, e.g. "/package.Service/Method"
 	HttpMethod     string             // the HTTP verb (GET, POST, PUT, DELETE, etc.)
-	PathPattern    string             // the URL path pattern for this route
+	PathPattern    string             // the URL path template of the HttpRule for this route
 	Handler        grpc.MethodHandler // the gRPC method handler function that processes the request
 	RequestField   KeyPath            // Specifies which field in the request message should be parsed from the HTTP request body
 	ResponseField  KeyPath            // Specifies which field in the response message to use as the HTTP response body
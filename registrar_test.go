@@ -2,11 +2,18 @@ package alchemy_test
 
 import (
 	"context"
+	"io"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/wjiec/alchemy"
+	"github.com/wjiec/alchemy/internal/testpb"
 )
 
 type EchoRequest struct{ Text string }
@@ -29,3 +36,126 @@ func RegisterEchoServiceServer(s alchemy.ServiceRegistrar, srv EchoServiceServer
 func TestWithServiceRegister(t *testing.T) {
 	assert.NotNil(t, alchemy.WithServiceRegister[EchoServiceServer](RegisterEchoServiceServer, &FakeEchoServiceImpl{}))
 }
+
+// EchoHandler returns a gRPC method handler which decodes the request into
+// a testpb.Proto3Message, tags it with the name, and responds with it.
+func EchoHandler(name string) grpc.MethodHandler {
+	return func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+		var message testpb.Proto3Message
+		if err := dec(&message); err != nil {
+			return nil, err
+		}
+
+		message.RepeatedString = append(message.RepeatedString, name)
+		return &message, nil
+	}
+}
+
+func TestApp_RegisterService(t *testing.T) {
+	baseUrl := ServeHttp(t, []alchemy.RouteDesc{
+		{
+			HttpMethod:     http.MethodGet,
+			PathPattern:    "/v1/{string_value=projects/*/books/*}",
+			Handler:        EchoHandler("GetBook"),
+			PathParameters: []string{"string_value"},
+		},
+		{
+			HttpMethod:     http.MethodPost,
+			PathPattern:    "/v1/{string_value=projects/*/books/*}:publish",
+			Handler:        EchoHandler("PublishBook"),
+			PathParameters: []string{"string_value"},
+		},
+		{
+			HttpMethod:     http.MethodGet,
+			PathPattern:    "/v1/shelves/{nested_value.string_value}/files/{nested_value.nested_value.string_value=**}",
+			Handler:        EchoHandler("GetFile"),
+			PathParameters: []string{"nested_value.string_value", "nested_value.nested_value.string_value"},
+		},
+		{
+			HttpMethod:     http.MethodGet,
+			PathPattern:    "/v1/*/users/{int64_value:[0-9]+}",
+			Handler:        EchoHandler("GetUser"),
+			PathParameters: []string{"int64_value"},
+		},
+	})
+
+	cases := []struct {
+		Method string
+		Path   string
+		Status int
+		Want   *testpb.Proto3Message
+	}{
+		{
+			Method: http.MethodGet,
+			Path:   "/v1/projects/p1/books/b1",
+			Status: http.StatusOK,
+			Want:   &testpb.Proto3Message{StringValue: "projects/p1/books/b1", RepeatedString: []string{"GetBook"}},
+		},
+		{
+			Method: http.MethodPost,
+			Path:   "/v1/projects/p1/books/b1:publish",
+			Status: http.StatusOK,
+			Want:   &testpb.Proto3Message{StringValue: "projects/p1/books/b1", RepeatedString: []string{"PublishBook"}},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/v1/shelves/s1/files/a/b/c.txt",
+			Status: http.StatusOK,
+			Want: &testpb.Proto3Message{
+				NestedValue: &testpb.Proto3Message{
+					StringValue: "s1",
+					NestedValue: &testpb.Proto3Message{StringValue: "a/b/c.txt"},
+				},
+				RepeatedString: []string{"GetFile"},
+			},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/v1/tenants/users/42",
+			Status: http.StatusOK,
+			Want:   &testpb.Proto3Message{Int64Value: 42, RepeatedString: []string{"GetUser"}},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/v1/projects/p1/books",
+			Status: http.StatusNotFound,
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/v1/tenants/users/me",
+			Status: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.Method+" "+tt.Path, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.Method, baseUrl+tt.Path, nil)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			if assert.Equal(t, tt.Status, resp.StatusCode) && tt.Want != nil {
+				body, _ := io.ReadAll(resp.Body)
+
+				var message testpb.Proto3Message
+				if assert.NoError(t, protojson.Unmarshal(body, &message)) {
+					assert.True(t, proto.Equal(tt.Want, &message), "want %v, got %v", tt.Want, &message)
+				}
+			}
+		})
+	}
+}
+
+func TestApp_RegisterService_InvalidPattern(t *testing.T) {
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(alchemy.TCP(":0")),
+		alchemy.WithServiceRegister(func(s alchemy.ServiceRegistrar, srv any) {
+			s.RegisterService(&alchemy.ServiceDesc{
+				Routes: []alchemy.RouteDesc{{HttpMethod: http.MethodGet, PathPattern: "/v1/{name=projects/{id}}"}},
+			}, srv)
+		}, any(nil)),
+	)
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, app.Start(context.Background()), "nested variables")
+}
@@ -0,0 +1,178 @@
+package alchemy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// configReloadDebounce is how long the reloading waits for the changes of the
+// configuration file to settle, since an editor may write a file several times.
+const configReloadDebounce = 100 * time.Millisecond
+
+// ConfigWithReload reloads the configuration file when it changes or the process
+// receives SIGHUP, the reloaded configuration is sent to the subscribers added by
+// ConfigSubscribe.
+//
+// The configuration is kept unchanged if the reloaded file fails to be parsed or
+// validated. The flags are not changed by the reloading.
+func ConfigWithReload() ConfigOption {
+	return func(loader *configLoader) error {
+		loader.reload = true
+		return nil
+	}
+}
+
+// ConfigSubscribe subscribes to the configuration, the fn is called with the
+// configuration decoded into a new T when the configuration is loaded and each
+// time it is reloaded, e.g. to change the log level without a restart.
+//
+// The T is decoded like ConfigWithDecode, a pointer to a proto message is
+// validated with protovalidate. The App fails to start if fn returns an error
+// for the initial configuration, the errors of the reloading are only logged.
+func ConfigSubscribe[T any](fn func(ctx context.Context, config T) error) ConfigOption {
+	return func(loader *configLoader) error {
+		loader.subscribers = append(loader.subscribers, configSubscriber{
+			decode: func(values map[string]any) (any, error) {
+				var config T
+				if typ := reflect.TypeFor[T](); typ.Kind() == reflect.Pointer {
+					config = reflect.New(typ.Elem()).Interface().(T)
+					return config, decodeConfig(values, config)
+				}
+				return config, decodeConfig(values, &config)
+			},
+			notify: func(ctx context.Context, config any) error {
+				return fn(ctx, config.(T))
+			},
+		})
+		return nil
+	}
+}
+
+// configSubscriber represents a subscriber of the configuration.
+type configSubscriber struct {
+	decode func(values map[string]any) (any, error)
+	notify func(ctx context.Context, config any) error
+}
+
+// notify decodes the values for each subscriber then notifies them, no subscriber
+// is notified if any of the decoding fails.
+func (l *configLoader) notify(ctx context.Context, values map[string]any) error {
+	configs := make([]any, len(l.subscribers))
+	for i, subscriber := range l.subscribers {
+		config, err := subscriber.decode(values)
+		if err != nil {
+			return fmt.Errorf("alchemy: decode config file %s: %w", l.file, err)
+		}
+		configs[i] = config
+	}
+
+	var errs []error
+	for i, subscriber := range l.subscribers {
+		if err := subscriber.notify(ctx, configs[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// startWatching starts watching the changes of the configuration file and SIGHUP,
+// it is run as a component so that the App is not ready until they are watched.
+func (l *configLoader) startWatching(ctx context.Context) error {
+	l.hup = make(chan os.Signal, 1)
+	signal.Notify(l.hup, syscall.SIGHUP)
+
+	if len(l.file) != 0 {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			signal.Stop(l.hup)
+			return err
+		}
+
+		// The directory is watched instead of the file, since the file may be
+		// replaced by a rename, e.g. by editors or the ConfigMap of Kubernetes.
+		if err = watcher.Add(filepath.Dir(l.file)); err != nil {
+			slog.Warn("Failed to watch config file, reloading on SIGHUP only", "file", l.file, "error", err)
+		}
+		l.watcher = watcher
+	}
+	return nil
+}
+
+// stopWatching stops watching the changes of the configuration file and SIGHUP.
+func (l *configLoader) stopWatching(ctx context.Context) error {
+	signal.Stop(l.hup)
+	if l.watcher != nil {
+		return l.watcher.Close()
+	}
+	return nil
+}
+
+// watch reloads the configuration file when it changes or the process receives
+// SIGHUP, until the context is canceled.
+func (l *configLoader) watch(ctx context.Context) error {
+	var changes <-chan fsnotify.Event
+	var watchErrors <-chan error
+	if l.watcher != nil {
+		changes, watchErrors = l.watcher.Events, l.watcher.Errors
+	}
+
+	debounce := time.NewTimer(0)
+	<-debounce.C
+	for {
+		select {
+		case <-ctx.Done():
+			debounce.Stop()
+			return nil
+		case <-l.hup:
+			l.reloadFile(ctx)
+		case event := <-changes:
+			if l.affectedBy(event) {
+				debounce.Reset(configReloadDebounce)
+			}
+		case err := <-watchErrors:
+			slog.Warn("Failed to watch config file", "file", l.file, "error", err)
+		case <-debounce.C:
+			l.reloadFile(ctx)
+		}
+	}
+}
+
+// affectedBy reports whether the configuration file is affected by the event.
+func (l *configLoader) affectedBy(event fsnotify.Event) bool {
+	if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+		return false
+	}
+
+	name := filepath.Clean(event.Name)
+	// The files of a Kubernetes ConfigMap are symlinks into the "..data" directory
+	// which is replaced atomically when the ConfigMap is updated.
+	return name == filepath.Clean(l.file) || filepath.Base(name) == "..data"
+}
+
+// reloadFile reloads the configuration file and notifies the subscribers, the
+// errors are logged since the App keeps running with the old configuration.
+func (l *configLoader) reloadFile(ctx context.Context) {
+	if len(l.file) == 0 {
+		return
+	}
+
+	values, err := readConfigFile(l.file)
+	if err == nil {
+		err = l.notify(ctx, values)
+	}
+	if err != nil {
+		slog.Error("Failed to reload config file", "file", l.file, "error", err)
+		return
+	}
+	slog.Info("Config file reloaded", "file", l.file)
+}
@@ -0,0 +1,145 @@
+package alchemy_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wjiec/alchemy"
+	"github.com/wjiec/alchemy/internal/testpb"
+)
+
+type ReloadConfig struct {
+	LogLevel  string `json:"log_level"`
+	RateLimit int    `json:"rate_limit"`
+}
+
+// ReceiveConfig waits for a config from the channel.
+func ReceiveConfig[T any](t *testing.T, configs <-chan T) T {
+	select {
+	case config := <-configs:
+		return config
+	case <-time.After(3 * time.Second):
+		require.FailNow(t, "config not received")
+	}
+	panic("unreachable")
+}
+
+// CaptureLogs captures the messages of the logs until the test ends.
+func CaptureLogs(t *testing.T) <-chan string {
+	messages := make(chan string, 32)
+	logger := slog.Default()
+	slog.SetDefault(slog.New(messageHandler(messages)))
+	t.Cleanup(func() { slog.SetDefault(logger) })
+
+	return messages
+}
+
+// messageHandler is a slog.Handler which sends the messages of the records to
+// the channel, the messages are dropped if the channel is full.
+type messageHandler chan<- string
+
+func (h messageHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h messageHandler) Handle(_ context.Context, record slog.Record) error {
+	select {
+	case h <- record.Message:
+	default:
+	}
+	return nil
+}
+
+func (h messageHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h messageHandler) WithGroup(string) slog.Handler { return h }
+
+// ReceiveLog waits for a log with the message from the channel.
+func ReceiveLog(t *testing.T, messages <-chan string, message string) {
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case received := <-messages:
+			if received == message {
+				return
+			}
+		case <-timeout:
+			require.FailNow(t, "log not received", message)
+		}
+	}
+}
+
+func TestConfigWithReload(t *testing.T) {
+	path := WriteConfigFile(t, "config.yaml", "log_level: info\nrate_limit: 10\n")
+
+	logs := CaptureLogs(t)
+	configs := make(chan ReloadConfig, 8)
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0")),
+		alchemy.WithConfig(
+			alchemy.ConfigWithFile(path),
+			alchemy.ConfigWithReload(),
+			alchemy.ConfigSubscribe(func(ctx context.Context, config ReloadConfig) error {
+				configs <- config
+				return nil
+			}),
+		),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Start(ctx) }()
+	defer func() { cancel(); assert.NoError(t, <-done) }()
+
+	assert.Equal(t, ReloadConfig{LogLevel: "info", RateLimit: 10}, ReceiveConfig(t, configs))
+
+	require.NoError(t, app.WaitReady(ctx)) // the config file is watched once ready
+	require.NoError(t, os.WriteFile(path, []byte("log_level: debug\nrate_limit: 20\n"), 0o644))
+	assert.Equal(t, ReloadConfig{LogLevel: "debug", RateLimit: 20}, ReceiveConfig(t, configs))
+
+	// The old config is kept if the file is invalid.
+	require.NoError(t, os.WriteFile(path, []byte("log_level: [warn\n"), 0o644))
+	ReceiveLog(t, logs, "Failed to reload config file")
+	assert.Empty(t, configs)
+
+	require.NoError(t, os.WriteFile(path, []byte("log_level: error\n"), 0o644))
+	assert.Equal(t, ReloadConfig{LogLevel: "error"}, ReceiveConfig(t, configs))
+
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	require.NoError(t, process.Signal(syscall.SIGHUP))
+	assert.Equal(t, ReloadConfig{LogLevel: "error"}, ReceiveConfig(t, configs))
+}
+
+func TestConfigSubscribe(t *testing.T) {
+	path := WriteConfigFile(t, "config.json", `{"string_value": "foobar", "int32_value": 32}`)
+
+	var received *testpb.Proto3Message
+	_, err := RunConfigCommand(t, []string{"--config", path}, alchemy.WithConfig(
+		alchemy.ConfigSubscribe(func(ctx context.Context, config *testpb.Proto3Message) error {
+			received = config
+			return nil
+		}),
+	))
+	require.NoError(t, err)
+	if assert.NotNil(t, received) {
+		assert.Equal(t, "foobar", received.GetStringValue())
+		assert.Equal(t, int32(32), received.GetInt32Value())
+	}
+
+	errRejected := errors.New("rejected")
+	_, err = RunConfigCommand(t, []string{"--config", path}, alchemy.WithConfig(
+		alchemy.ConfigSubscribe(func(ctx context.Context, config map[string]any) error {
+			assert.Equal(t, "foobar", config["string_value"])
+			return errRejected
+		}),
+	))
+	assert.ErrorIs(t, err, errRejected)
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package alchemy
+
+import (
+	"errors"
+	"runtime"
+	"time"
+)
+
+// WithGracefulRestart enables the zero-downtime restart of the App, which is
+// only supported on unix systems.
+func WithGracefulRestart(readyTimeout time.Duration) AppOption {
+	return func(app *App) error {
+		return errors.New("alchemy: graceful restart is not supported on " + runtime.GOOS)
+	}
+}
@@ -0,0 +1,221 @@
+//go:build unix
+
+package alchemy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// envRestartListeners lists the names of the servers whose listeners are
+	// passed to the new process, in order of the file descriptors from 3.
+	envRestartListeners = "ALCHEMY_RESTART_LISTENERS"
+
+	// envRestartReadyFd is the file descriptor the new process reports ready to.
+	envRestartReadyFd = "ALCHEMY_RESTART_READY_FD"
+)
+
+// WithGracefulRestart enables the zero-downtime restart of the App.
+//
+// On SIGUSR2 the App re-executes its binary with the same arguments, passing the
+// listeners of its servers to the new process. Once the new process reports
+// ready, the App shuts down gracefully as if Stop is called. The App keeps
+// running if the new process fails or is not ready in readyTimeout.
+func WithGracefulRestart(readyTimeout time.Duration) AppOption {
+	return func(app *App) error {
+		restart := &gracefulRestart{app: app, readyTimeout: readyTimeout}
+		name := reservedComponentPrefix + "graceful-restart"
+		app.components = append(app.components, &component{name: name, impl: NewComponent(restart.start, restart.stop)})
+		app.workers = append(app.workers, &worker{name: name, run: restart.run})
+		return nil
+	}
+}
+
+// gracefulRestart manages the graceful restart of an App.
+type gracefulRestart struct {
+	app          *App
+	readyTimeout time.Duration
+	readyFile    *os.File
+	signals      chan os.Signal
+}
+
+// start inherits the listeners of the parent process and starts handling SIGUSR2,
+// it is run as a component so that the App is not ready until SIGUSR2 is handled.
+func (r *gracefulRestart) start(ctx context.Context) error {
+	if err := r.inherit(); err != nil {
+		return err
+	}
+
+	r.signals = make(chan os.Signal, 1)
+	signal.Notify(r.signals, syscall.SIGUSR2)
+	return nil
+}
+
+// stop stops handling SIGUSR2.
+func (r *gracefulRestart) stop(ctx context.Context) error {
+	signal.Stop(r.signals)
+	return nil
+}
+
+// inherit replaces the addresses of the servers with the listeners passed by
+// the parent process, if the App is started by a graceful restart.
+func (r *gracefulRestart) inherit() error {
+	listeners, found := os.LookupEnv(envRestartListeners)
+	if !found {
+		return nil
+	}
+	_ = os.Unsetenv(envRestartListeners)
+
+	for i, name := range strings.Split(listeners, ",") {
+		addr := NewFileAddr(uintptr(3 + i))
+		switch {
+		case name == "grpc" && r.app.grpcServer != nil:
+			r.app.grpcServer.addr = addr
+		case name == "http" && r.app.httpServer != nil:
+			r.app.httpServer.addr = addr
+		}
+	}
+
+	if value, found := os.LookupEnv(envRestartReadyFd); found {
+		_ = os.Unsetenv(envRestartReadyFd)
+		fd, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("alchemy: invalid %s: %w", envRestartReadyFd, err)
+		}
+		r.readyFile = os.NewFile(uintptr(fd), "ready")
+	}
+	return nil
+}
+
+// run reports ready to the parent process if any, then restarts the App on SIGUSR2.
+func (r *gracefulRestart) run(ctx context.Context) error {
+	if r.readyFile != nil {
+		select {
+		case <-r.app.Ready():
+			_, _ = r.readyFile.Write([]byte{1})
+		case <-ctx.Done():
+		}
+		_ = r.readyFile.Close()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.signals:
+			slog.Info("Graceful restart requested")
+			if err := r.restart(ctx); err != nil {
+				slog.Error("Graceful restart failed", "error", err)
+				continue
+			}
+
+			slog.Info("Graceful restart succeeded, shutting down")
+			r.app.Stop()
+			return nil
+		}
+	}
+}
+
+// restart starts the new process with the listeners of the servers and waits
+// until it reports ready, the new process is killed if it fails to be ready.
+func (r *gracefulRestart) restart(ctx context.Context) error {
+	var names []string
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	for name, l := range r.listeners() {
+		filer, ok := l.(interface{ File() (*os.File, error) })
+		if !ok {
+			return fmt.Errorf("alchemy: listener of %s server cannot be passed", name)
+		}
+		f, err := filer.File()
+		if err != nil {
+			return err
+		}
+		names, files = append(names, name), append(files, f)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = readyReader.Close() }()
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = append(slices.Clone(files), readyWriter)
+	cmd.Env = slices.DeleteFunc(os.Environ(), func(env string) bool {
+		return strings.HasPrefix(env, envRestartListeners+"=") || strings.HasPrefix(env, envRestartReadyFd+"=")
+	})
+	cmd.Env = append(cmd.Env,
+		envRestartListeners+"="+strings.Join(names, ","),
+		envRestartReadyFd+"="+strconv.Itoa(3+len(files)),
+	)
+
+	err = cmd.Start()
+	_ = readyWriter.Close()
+	if err != nil {
+		return err
+	}
+
+	ready := make(chan error, 1)
+	go func() {
+		if _, err := readyReader.Read(make([]byte, 1)); err != nil {
+			ready <- errors.New("alchemy: new process exited before ready")
+			return
+		}
+		ready <- nil
+	}()
+	go func() { _ = cmd.Wait() }()
+
+	timer := time.NewTimer(r.readyTimeout)
+	defer timer.Stop()
+
+	select {
+	case err = <-ready:
+	case <-timer.C:
+		err = errors.New("alchemy: new process not ready in time")
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+	}
+	return err
+}
+
+// listeners yields the names and the listeners of the listening servers.
+func (r *gracefulRestart) listeners() iter.Seq2[string, net.Listener] {
+	return func(yield func(string, net.Listener) bool) {
+		if r.app.grpcServer != nil {
+			if l := r.app.grpcServer.Listener(); l != nil && !yield("grpc", l) {
+				return
+			}
+		}
+		if r.app.httpServer != nil {
+			if l := r.app.httpServer.Listener(); l != nil {
+				yield("http", l)
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+//go:build unix
+
+package alchemy_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wjiec/alchemy"
+)
+
+// NewRestartApp creates an App with graceful restart which responds its role at "/who"
+// and stops at "/stop".
+func NewRestartApp(t *testing.T, addr alchemy.Addr, role string) *alchemy.App {
+	var app *alchemy.App
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(addr,
+			alchemy.HttpWithAdditionalHandler(http.MethodGet, "/who", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				_, _ = w.Write([]byte(role))
+			})),
+			alchemy.HttpWithAdditionalHandler(http.MethodGet, "/stop", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				app.Stop()
+			})),
+		),
+		alchemy.WithGracefulRestart(10*time.Second),
+	)
+	require.NoError(t, err)
+
+	return app
+}
+
+func TestWithGracefulRestart(t *testing.T) {
+	if os.Getenv("ALCHEMY_TEST_RESTART_CHILD") == "1" {
+		// The new process started by the graceful restart, which serves on the
+		// listener passed by the parent until stopped.
+		require.NoError(t, NewRestartApp(t, alchemy.TCP("127.0.0.1:0"), "child").Start(context.Background()))
+		return
+	}
+
+	// Re-execute this test only in the new process and discard its output.
+	args, stdout := os.Args, os.Stdout
+	defer func() { os.Args, os.Stdout = args, stdout }()
+	os.Args = []string{args[0], "-test.run=^TestWithGracefulRestart$"}
+	os.Stdout, _ = os.Open(os.DevNull)
+	t.Setenv("ALCHEMY_TEST_RESTART_CHILD", "1")
+
+	app := NewRestartApp(t, alchemy.TCP("127.0.0.1:0"), "parent")
+	done := make(chan error, 1)
+	go func() { done <- app.Start(context.Background()) }()
+	require.NoError(t, app.WaitReady(context.Background()))
+
+	baseUrl := "http://" + app.HttpAddr().String()
+	get := func(path string) string {
+		resp, err := http.Get(baseUrl + path)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	assert.Equal(t, "parent", get("/who"))
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR2))
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(15 * time.Second):
+		require.FailNow(t, "parent not stopped after restart")
+	}
+
+	assert.Equal(t, "child", get("/who"))
+	get("/stop")
+}
+
+func TestWithGracefulRestart_ComponentName(t *testing.T) {
+	var events []string
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0")),
+		alchemy.WithComponent("graceful-restart", RecordComponent("graceful-restart", &events, nil)),
+		alchemy.WithGracefulRestart(time.Second),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Start(ctx) }()
+	require.NoError(t, app.WaitReady(ctx))
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"start graceful-restart", "stop graceful-restart"}, events)
+}
@@ -0,0 +1,117 @@
+package alchemy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// Route describes an HTTP route registered to the App.
+type Route struct {
+	HttpMethod     string   `json:"http_method"`               // the HTTP verb of the route
+	PathPattern    string   `json:"path_pattern"`              // the URL path template of the route
+	FullMethod     string   `json:"full_method,omitempty"`     // the full name of the gRPC method, empty for the additional handlers
+	PathParameters []string `json:"path_parameters,omitempty"` // the fields of the request bound by the path
+	RequestField   string   `json:"request_field,omitempty"`   // the field of the request parsed from the body, empty for the whole request
+	ResponseField  string   `json:"response_field,omitempty"`  // the field of the response written as the body, empty for the whole response
+}
+
+// Routes returns the HTTP routes registered to the App, including the additional
+// handlers, in the order in which they are registered.
+//
+// The services are registered to the App before the routes are collected,
+// it returns nil if the App has no HTTP server.
+func (a *App) Routes() []Route {
+	a.registerServices()
+	if a.httpServer == nil {
+		return nil
+	}
+	return a.httpServer.routeList()
+}
+
+// routeList returns the routes registered to the server.
+func (hs *httpServer) routeList() []Route {
+	routes := make([]Route, 0, len(hs.routes)+len(hs.additionalRoutes))
+	for _, route := range hs.routes {
+		routes = append(routes, Route{
+			HttpMethod:     route.desc.HttpMethod,
+			PathPattern:    route.desc.PathPattern,
+			FullMethod:     route.desc.FullMethod,
+			PathParameters: route.desc.PathParameters,
+			RequestField:   route.desc.RequestField.Name,
+			ResponseField:  route.desc.ResponseField.Name,
+		})
+	}
+	for _, route := range hs.additionalRoutes {
+		routes = append(routes, Route{HttpMethod: route.HttpMethod, PathPattern: route.PathPattern})
+	}
+	return routes
+}
+
+// HttpWithRoutesEndpoint returns an HttpOption that serves the routes registered
+// to the server as JSON at the given path, e.g. "/debug/routes".
+//
+// The endpoint exposes the API surface of the application, so it should not be
+// enabled for servers reachable from untrusted networks.
+func HttpWithRoutesEndpoint(pattern string) HttpOption {
+	return func(hs *httpServer) error {
+		return HttpWithAdditionalHandler(http.MethodGet, pattern, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(hs.routeList())
+		}))(hs)
+	}
+}
+
+// WithRoutesCommand returns an AppOption that adds a "routes" subcommand to the App,
+// which prints the registered HTTP routes as a table or as JSON.
+func WithRoutesCommand() AppOption {
+	return func(app *App) error {
+		var output string
+		cmd := &cobra.Command{
+			Use:   "routes",
+			Short: "Print the registered HTTP routes",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return printRoutes(cmd.OutOrStdout(), output, app.Routes())
+			},
+		}
+		cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format, one of: table, json")
+
+		app.root.AddCommand(cmd)
+		return nil
+	}
+}
+
+// printRoutes writes the routes to w in the given format.
+func printRoutes(w io.Writer, format string, routes []Route) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(routes)
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "METHOD\tPATTERN\tRPC\tPATH PARAMETERS\tBODY\tRESPONSE BODY")
+		for _, route := range routes {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", route.HttpMethod, route.PathPattern,
+				orDash(route.FullMethod), orDash(strings.Join(route.PathParameters, ",")),
+				orDash(route.RequestField), orDash(route.ResponseField))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("alchemy: unknown output format %q", format)
+	}
+}
+
+// orDash returns s, or "-" if s is empty.
+func orDash(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,134 @@
+package alchemy_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wjiec/alchemy"
+)
+
+var testRoutes = []alchemy.RouteDesc{
+	{
+		FullMethod:     "/library.v1.LibraryService/GetBook",
+		HttpMethod:     http.MethodGet,
+		PathPattern:    "/v1/{name=shelves/*/books/*}",
+		PathParameters: []string{"name"},
+	},
+	{
+		FullMethod:     "/library.v1.LibraryService/CreateBook",
+		HttpMethod:     http.MethodPost,
+		PathPattern:    "/v1/{parent=shelves/*}/books",
+		RequestField:   alchemy.KeyPath{Name: "book"},
+		PathParameters: []string{"parent"},
+	},
+}
+
+var wantRoutes = []alchemy.Route{
+	{
+		HttpMethod:     http.MethodGet,
+		PathPattern:    "/v1/{name=shelves/*/books/*}",
+		FullMethod:     "/library.v1.LibraryService/GetBook",
+		PathParameters: []string{"name"},
+	},
+	{
+		HttpMethod:     http.MethodPost,
+		PathPattern:    "/v1/{parent=shelves/*}/books",
+		FullMethod:     "/library.v1.LibraryService/CreateBook",
+		PathParameters: []string{"parent"},
+		RequestField:   "book",
+	},
+}
+
+func TestApp_Routes(t *testing.T) {
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(alchemy.TCP(":0"),
+			alchemy.HttpWithAdditionalHandler(http.MethodGet, "/healthz", http.NotFoundHandler()),
+		),
+		alchemy.WithServiceRegister(func(s alchemy.ServiceRegistrar, srv any) {
+			s.RegisterService(&alchemy.ServiceDesc{Routes: testRoutes}, srv)
+		}, any(nil)),
+	)
+	require.NoError(t, err)
+
+	want := append(wantRoutes, alchemy.Route{HttpMethod: http.MethodGet, PathPattern: "/healthz"})
+	assert.Equal(t, want, app.Routes())
+	assert.Equal(t, want, app.Routes())
+}
+
+func TestApp_Routes_WithoutHttpServer(t *testing.T) {
+	app, err := alchemy.New(t.Name())
+	require.NoError(t, err)
+
+	assert.Nil(t, app.Routes())
+}
+
+func TestHttpWithRoutesEndpoint(t *testing.T) {
+	baseUrl := ServeHttp(t, testRoutes, alchemy.HttpWithRoutesEndpoint("/debug/routes"))
+
+	resp, err := http.Get(baseUrl + "/debug/routes")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var routes []alchemy.Route
+	if assert.NoError(t, json.NewDecoder(resp.Body).Decode(&routes)) {
+		assert.Equal(t, append(wantRoutes, alchemy.Route{HttpMethod: http.MethodGet, PathPattern: "/debug/routes"}), routes)
+	}
+}
+
+func TestWithRoutesCommand(t *testing.T) {
+	run := func(t *testing.T, args ...string) (string, error) {
+		var out bytes.Buffer
+		app, err := alchemy.New(t.Name(),
+			alchemy.WithHttpServer(alchemy.TCP(":0")),
+			alchemy.WithServiceRegister(func(s alchemy.ServiceRegistrar, srv any) {
+				s.RegisterService(&alchemy.ServiceDesc{Routes: testRoutes}, srv)
+			}, any(nil)),
+			alchemy.WithRoutesCommand(),
+			alchemy.WithBeforeStart(func(ctx context.Context, root *cobra.Command) error {
+				root.SetArgs(append([]string{"routes"}, args...))
+				root.SetOut(&out)
+				return nil
+			}),
+		)
+		require.NoError(t, err)
+
+		err = app.Start(context.Background())
+		return out.String(), err
+	}
+
+	t.Run("table", func(t *testing.T) {
+		out, err := run(t)
+		if assert.NoError(t, err) {
+			assert.Equal(t, ""+
+				"METHOD  PATTERN                       RPC                                    PATH PARAMETERS  BODY  RESPONSE BODY\n"+
+				"GET     /v1/{name=shelves/*/books/*}  /library.v1.LibraryService/GetBook     name             -     -\n"+
+				"POST    /v1/{parent=shelves/*}/books  /library.v1.LibraryService/CreateBook  parent           book  -\n",
+				out)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		out, err := run(t, "--output", "json")
+		if assert.NoError(t, err) {
+			var routes []alchemy.Route
+			if assert.NoError(t, json.Unmarshal([]byte(out), &routes)) {
+				assert.Equal(t, wantRoutes, routes)
+			}
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := run(t, "-o", "yaml")
+		assert.ErrorContains(t, err, `unknown output format "yaml"`)
+	})
+}
@@ -0,0 +1,174 @@
+package alchemy
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// server represents a server managed by the App.
+type server interface {
+	// Start starts the server and blocks until the server is stopped.
+	Start(ctx context.Context) error
+
+	// Ready returns a channel which is closed once the server is listening.
+	Ready() <-chan struct{}
+
+	// Shutdown stops the server gracefully, the server is stopped forcibly if
+	// the context is done before all the requests are completed.
+	Shutdown(ctx context.Context) error
+}
+
+// servers returns the servers configured for the App.
+func (a *App) servers() []server {
+	var servers []server
+	if a.grpcServer != nil {
+		servers = append(servers, a.grpcServer)
+	}
+	if a.httpServer != nil {
+		servers = append(servers, a.httpServer)
+	}
+	return servers
+}
+
+// Healthy reports whether the App is serving and not shutting down.
+func (a *App) Healthy() bool {
+	return a.healthy.Load()
+}
+
+// Stop requests the App to shut down gracefully as if the context passed to
+// Start is canceled, it returns immediately without waiting for the App to stop.
+//
+// Calling Stop again once the App is shutting down skips the rest of the drain
+// delay, e.g. on the second shutdown signal with SignalWithSecondSignal.
+func (a *App) Stop() {
+	if a.shuttingDown.Load() {
+		a.forceOnce.Do(func() { close(a.forcing) })
+		return
+	}
+	a.stopOnce.Do(func() { close(a.stopping) })
+}
+
+// shutdown stops the servers of the App.
+//
+// The App is marked as unhealthy first, then if the shutdown is requested by the
+// cancellation of ctx, it waits for the drain delay so that the load balancers
+// notice the App is going away. The servers stop accepting new requests and are
+// stopped forcibly if the requests are not completed in the shutdown timeout.
+//
+// The shutdown timeout starts before the drain delay, so the drain delay is cut
+// short by the timeout or by Stop called again.
+func (a *App) shutdown(ctx context.Context, servers []server) {
+	a.shuttingDown.Store(true)
+	a.healthy.Store(false)
+	if a.grpcServer != nil && a.grpcServer.health != nil {
+		a.grpcServer.health.Shutdown()
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+	defer cancel()
+
+	if ctx.Err() != nil && a.drainDelay > 0 {
+		slog.Info("Draining before shutdown", "delay", a.drainDelay)
+		timer := time.NewTimer(a.drainDelay)
+		select {
+		case <-timer.C:
+		case <-a.forcing:
+			timer.Stop()
+			slog.Info("Draining interrupted")
+		case <-shutdownCtx.Done():
+			timer.Stop()
+			slog.Warn("Draining exceeded the shutdown timeout", "timeout", a.shutdownTimeout)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, s := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Shutdown(shutdownCtx); err != nil {
+				slog.Warn("Server stopped forcibly", "error", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// runShutdownHooks runs the shutdown hooks in reverse order of registration.
+func (a *App) runShutdownHooks() error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, hook := range slices.Backward(a.onShutdown) {
+		if err := hook(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ShutdownHook represents the hooks executed after the servers of the App are stopped.
+type ShutdownHook func(ctx context.Context) error
+
+// WithOnShutdown adds a ShutdownHook to be run when the application stops,
+// e.g. to close the database pools or flush the telemetry.
+//
+// The hooks are run in reverse order of registration after the servers and the
+// components are stopped, all the hooks are run even if some of them fail.
+func WithOnShutdown(hook ShutdownHook) AppOption {
+	return func(app *App) error {
+		app.onShutdown = append(app.onShutdown, hook)
+		return nil
+	}
+}
+
+// WithDrainDelay configures how long the App keeps serving after being marked
+// as unhealthy on shutdown, which gives the load balancers the time to stop
+// routing new requests to it.
+//
+// The drain delay is part of the shutdown timeout, which should be longer than
+// the delay to leave the time for the in-flight requests to complete. The delay
+// is skipped if Stop is called again during it.
+func WithDrainDelay(delay time.Duration) AppOption {
+	return func(app *App) error {
+		app.drainDelay = delay
+		return nil
+	}
+}
+
+// WithShutdownTimeout configures how long the App waits for the in-flight requests
+// to complete on shutdown before stopping the servers forcibly.
+//
+// The timeout includes the drain delay, and the HTTP server waits for at most the
+// shorter of the time left and its HttpWithGracefulShutdownTimeout. For example,
+// with a drain delay of 5s, a shutdown timeout of 30s and the default graceful
+// timeout of 3s, the HTTP server is stopped forcibly at most 8s and the gRPC server
+// at most 30s after the shutdown starts. The same timeout is then applied again
+// to the shutdown hooks.
+func WithShutdownTimeout(timeout time.Duration) AppOption {
+	return func(app *App) error {
+		app.shutdownTimeout = timeout
+		return nil
+	}
+}
+
+// HttpWithHealthEndpoint returns an HttpOption that serves the health of the App
+// at the given path, it responds 200 OK while the App is healthy and 503 Service
+// Unavailable once the App starts shutting down.
+func HttpWithHealthEndpoint(pattern string) HttpOption {
+	return func(hs *httpServer) error {
+		return HttpWithAdditionalHandler(http.MethodGet, pattern, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if hs.healthy == nil || !hs.healthy() {
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte("ok"))
+		}))(hs)
+	}
+}
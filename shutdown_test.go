@@ -0,0 +1,192 @@
+package alchemy_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/wjiec/alchemy"
+)
+
+func TestWithOnShutdown(t *testing.T) {
+	var order []string
+	hook := func(name string, err error) alchemy.ShutdownHook {
+		return func(ctx context.Context) error {
+			_, hasDeadline := ctx.Deadline()
+			assert.True(t, hasDeadline)
+
+			order = append(order, name)
+			return err
+		}
+	}
+
+	errFlush := errors.New("flush failed")
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0")),
+		alchemy.WithOnShutdown(hook("database", nil)),
+		alchemy.WithOnShutdown(hook("telemetry", errFlush)),
+		alchemy.WithOnShutdown(hook("cache", nil)),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, app.Start(ctx), errFlush)
+	assert.Equal(t, []string{"cache", "telemetry", "database"}, order)
+}
+
+func TestWithDrainDelay(t *testing.T) {
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0"), alchemy.HttpWithHealthEndpoint("/healthz")),
+		alchemy.WithDrainDelay(300*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Start(ctx) }()
+	require.NoError(t, app.WaitReady(ctx))
+	addr := app.HttpAddr().String()
+
+	healthz := func() int {
+		resp, err := http.Get("http://" + addr + "/healthz")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.True(t, app.Healthy())
+	assert.Equal(t, http.StatusOK, healthz())
+
+	cancel()
+	require.Eventually(t, func() bool { return !app.Healthy() }, time.Second, 10*time.Millisecond)
+	// The server keeps serving while draining.
+	assert.Equal(t, http.StatusServiceUnavailable, healthz())
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("app not stopped")
+	}
+}
+
+func TestWithDrainDelay_Interrupted(t *testing.T) {
+	cases := []struct {
+		Name    string
+		Options []alchemy.AppOption
+		Stop    bool
+	}{
+		{Name: "stop again", Stop: true},
+		{Name: "shutdown timeout", Options: []alchemy.AppOption{alchemy.WithShutdownTimeout(200 * time.Millisecond)}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			app, err := alchemy.New(t.Name(), append([]alchemy.AppOption{
+				alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0")),
+				alchemy.WithDrainDelay(10 * time.Second),
+			}, c.Options...)...)
+			require.NoError(t, err)
+
+			done := make(chan error, 1)
+			go func() { done <- app.Start(context.Background()) }()
+			require.NoError(t, app.WaitReady(context.Background()))
+
+			start := time.Now()
+			app.Stop()
+			require.Eventually(t, func() bool { return !app.Healthy() }, time.Second, 10*time.Millisecond)
+			if c.Stop {
+				app.Stop()
+			}
+
+			select {
+			case err := <-done:
+				assert.NoError(t, err)
+				assert.Less(t, time.Since(start), 2*time.Second)
+			case <-time.After(5 * time.Second):
+				t.Fatal("draining not interrupted")
+			}
+		})
+	}
+}
+
+func TestWithShutdownTimeout(t *testing.T) {
+	handling := make(chan struct{})
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0"),
+			alchemy.HttpWithAdditionalHandler(http.MethodGet, "/slow", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				close(handling)
+				select {
+				case <-req.Context().Done():
+				case <-time.After(10 * time.Second):
+				}
+			})),
+		),
+		alchemy.WithShutdownTimeout(200*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Start(ctx) }()
+	require.NoError(t, app.WaitReady(ctx))
+	addr := app.HttpAddr().String()
+
+	go func() {
+		if resp, err := http.Get("http://" + addr + "/slow"); err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+	<-handling
+
+	start := time.Now()
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), 2*time.Second)
+	case <-time.After(5 * time.Second):
+		t.Fatal("app not stopped forcibly")
+	}
+}
+
+func TestGrpcWithHealthService(t *testing.T) {
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithGrpcServer(alchemy.TCP("127.0.0.1:0"), alchemy.GrpcWithHealthService()),
+		alchemy.WithDrainDelay(300*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- app.Start(ctx) }()
+	require.NoError(t, app.WaitReady(ctx))
+	addr := app.GrpcAddr().String()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	client := healthpb.NewHealthClient(conn)
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	cancel()
+	require.Eventually(t, func() bool { return !app.Healthy() }, time.Second, 10*time.Millisecond)
+	resp, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+
+	assert.NoError(t, <-done)
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"runtime/pprof"
+	"slices"
+	"sync"
 	"syscall"
 )
 
@@ -15,16 +18,135 @@ var onlyOneSignalHandler = make(chan struct{})
 // If a second signal is caught, the program is terminated with exit code 1.
 func SetupSignalHandler() context.Context {
 	close(onlyOneSignalHandler) // panics when called twice
-	ctx, cancel := context.WithCancel(context.Background())
+
+	return NewSignalHandler().Start(context.Background())
+}
+
+// SignalHandler handles the signals of the process, the shutdown signals cancel
+// the context returned by Start and the other signals are passed to the callbacks.
+type SignalHandler struct {
+	shutdownSignals []os.Signal
+	onSecondSignal  func(sig os.Signal)
+	callbacks       map[os.Signal][]func(sig os.Signal)
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSignalHandler creates a SignalHandler configured by the options.
+//
+// By default, the shutdown signals are SIGINT, SIGTERM and SIGQUIT, and the
+// program is terminated with exit code 1 on the second shutdown signal.
+func NewSignalHandler(options ...SignalOption) *SignalHandler {
+	h := &SignalHandler{
+		shutdownSignals: []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT},
+		onSecondSignal:  func(os.Signal) { os.Exit(1) },
+		callbacks:       make(map[os.Signal][]func(os.Signal)),
+		stop:            make(chan struct{}),
+	}
+	for _, applySignalOption := range options {
+		applySignalOption(h)
+	}
+
+	return h
+}
+
+// SignalOption used to configure a SignalHandler.
+type SignalOption func(h *SignalHandler)
+
+// SignalWithShutdownSignals configures the signals canceling the context returned by Start.
+func SignalWithShutdownSignals(signals ...os.Signal) SignalOption {
+	return func(h *SignalHandler) {
+		h.shutdownSignals = signals
+	}
+}
+
+// SignalWithSecondSignal configures the function called on each shutdown signal
+// after the first one, a nil function ignores them.
+func SignalWithSecondSignal(fn func(sig os.Signal)) SignalOption {
+	return func(h *SignalHandler) {
+		h.onSecondSignal = fn
+	}
+}
+
+// SignalWithCallback adds a callback called on the signal, e.g. SIGUSR1 with
+// DumpGoroutines. The callbacks are called one at a time in the order added.
+//
+// The callbacks are called on a goroutine other than the one handling the
+// shutdown signals, so that a slow callback does not delay the shutdown. The
+// signals received while the queued callbacks are running may be dropped.
+func SignalWithCallback(sig os.Signal, fn func(sig os.Signal)) SignalOption {
+	return func(h *SignalHandler) {
+		h.callbacks[sig] = append(h.callbacks[sig], fn)
+	}
+}
+
+// Start starts handling the signals, and returns a context derived from parent
+// which is canceled on the first shutdown signal.
+//
+// Start should be called only once for a SignalHandler.
+func (h *SignalHandler) Start(parent context.Context) context.Context {
+	ctx, cancel := context.WithCancel(parent)
+
+	signals := slices.Clone(h.shutdownSignals)
+	for sig := range h.callbacks {
+		signals = append(signals, sig)
+	}
+
+	pending := make(chan os.Signal, 8)
+	go func() {
+		for {
+			select {
+			case <-h.stop:
+				return
+			case sig := <-pending:
+				for _, callback := range h.callbacks[sig] {
+					callback(sig)
+				}
+			}
+		}
+	}()
 
 	c := make(chan os.Signal, 2)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT)
+	signal.Notify(c, signals...)
 	go func() {
-		<-c
-		cancel()
-		<-c
-		os.Exit(1) // second signal. Exit directly.
+		defer cancel()
+		defer signal.Stop(c)
+
+		var shutdowns int
+		for {
+			select {
+			case <-h.stop:
+				return
+			case sig := <-c:
+				if len(h.callbacks[sig]) != 0 {
+					select {
+					case pending <- sig:
+					default:
+					}
+				}
+
+				if slices.Contains(h.shutdownSignals, sig) {
+					if shutdowns++; shutdowns == 1 {
+						cancel()
+					} else if h.onSecondSignal != nil {
+						h.onSecondSignal(sig)
+					}
+				}
+			}
+		}
 	}()
 
 	return ctx
 }
+
+// Stop stops handling the signals and cancels the context returned by Start.
+func (h *SignalHandler) Stop() {
+	h.stopOnce.Do(func() { close(h.stop) })
+}
+
+// DumpGoroutines writes the stack traces of all the goroutines to the standard
+// error, which is intended to be used as a callback of SignalWithCallback.
+func DumpGoroutines(_ os.Signal) {
+	_ = pprof.Lookup("goroutine").WriteTo(os.Stderr, 2)
+}
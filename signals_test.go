@@ -1,9 +1,16 @@
 package alchemy_test
 
 import (
+	"context"
+	"io"
+	"os"
+	"runtime"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/wjiec/alchemy"
 )
@@ -19,3 +26,109 @@ func TestSignals(t *testing.T) {
 		})
 	})
 }
+
+// RaiseSignal sends the signal to the current process.
+func RaiseSignal(t *testing.T, sig os.Signal) {
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	require.NoError(t, process.Signal(sig))
+}
+
+func TestSignalHandler(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signals cannot be sent on windows")
+	}
+
+	t.Run("callback", func(t *testing.T) {
+		received := make(chan os.Signal, 1)
+		handler := alchemy.NewSignalHandler(alchemy.SignalWithCallback(syscall.SIGHUP, func(sig os.Signal) {
+			received <- sig
+		}))
+		ctx := handler.Start(context.Background())
+		defer handler.Stop()
+
+		RaiseSignal(t, syscall.SIGHUP)
+		select {
+		case sig := <-received:
+			assert.Equal(t, syscall.SIGHUP, sig)
+		case <-time.After(time.Second):
+			assert.Fail(t, "callback not called")
+		}
+		assert.NoError(t, ctx.Err())
+	})
+
+	t.Run("shutdown", func(t *testing.T) {
+		second := make(chan os.Signal, 1)
+		handler := alchemy.NewSignalHandler(
+			alchemy.SignalWithShutdownSignals(syscall.SIGHUP),
+			alchemy.SignalWithSecondSignal(func(sig os.Signal) { second <- sig }),
+		)
+		ctx := handler.Start(context.Background())
+		defer handler.Stop()
+
+		RaiseSignal(t, syscall.SIGHUP)
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			assert.Fail(t, "context not canceled")
+		}
+
+		RaiseSignal(t, syscall.SIGHUP)
+		select {
+		case sig := <-second:
+			assert.Equal(t, syscall.SIGHUP, sig)
+		case <-time.After(time.Second):
+			assert.Fail(t, "second signal not handled")
+		}
+	})
+
+	t.Run("slow callback", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		handler := alchemy.NewSignalHandler(
+			alchemy.SignalWithShutdownSignals(syscall.SIGHUP),
+			alchemy.SignalWithCallback(syscall.SIGHUP, func(os.Signal) { <-release }),
+		)
+		ctx := handler.Start(context.Background())
+		defer handler.Stop()
+
+		RaiseSignal(t, syscall.SIGHUP)
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			assert.Fail(t, "context not canceled")
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		handler := alchemy.NewSignalHandler()
+		ctx := handler.Start(context.Background())
+
+		handler.Stop()
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			assert.Fail(t, "context not canceled")
+		}
+	})
+}
+
+func TestDumpGoroutines(t *testing.T) {
+	stderr := os.Stderr
+	defer func() { os.Stderr = stderr }()
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stderr = w
+
+	dump := make(chan []byte, 1)
+	go func() {
+		data, _ := io.ReadAll(r)
+		dump <- data
+	}()
+
+	alchemy.DumpGoroutines(syscall.SIGHUP)
+	require.NoError(t, w.Close())
+	assert.Contains(t, string(<-dump), "TestDumpGoroutines")
+}
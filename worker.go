@@ -0,0 +1,116 @@
+package alchemy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Worker represents a long-running background task managed by the App, e.g.
+// a message consumer, a scheduler or a cache refresher.
+//
+// The context is canceled when the App is shutting down, the worker is
+// expected to return as soon as possible then.
+type Worker func(ctx context.Context) error
+
+// worker represents a named Worker registered to the App.
+type worker struct {
+	name string
+	run  Worker
+
+	restart     bool
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	maxRestarts int
+}
+
+// WithWorker registers a named Worker which runs along with the servers of the App.
+//
+// By default, a failed worker takes down the App, which can be changed to restart
+// the worker by WorkerWithRestart. A worker returning nil is not restarted.
+func WithWorker(name string, run Worker, options ...WorkerOption) AppOption {
+	return func(app *App) error {
+		w := &worker{name: name, run: run}
+		for _, applyWorkerOption := range options {
+			if err := applyWorkerOption(w); err != nil {
+				return err
+			}
+		}
+
+		app.workers = append(app.workers, w)
+		return nil
+	}
+}
+
+// WorkerOption used to configure a Worker registered to the App.
+type WorkerOption func(w *worker) error
+
+// WorkerWithRestart restarts the failed worker instead of taking down the App.
+//
+// The worker is restarted after a backoff which starts at minBackoff and doubles
+// after each consecutive failure up to maxBackoff. The backoff is reset once the
+// worker has run for longer than maxBackoff.
+func WorkerWithRestart(minBackoff, maxBackoff time.Duration) WorkerOption {
+	return func(w *worker) error {
+		if minBackoff <= 0 || maxBackoff < minBackoff {
+			return fmt.Errorf("alchemy: invalid backoff [%s, %s] of worker %q", minBackoff, maxBackoff, w.name)
+		}
+
+		w.restart = true
+		w.minBackoff, w.maxBackoff = minBackoff, maxBackoff
+		return nil
+	}
+}
+
+// WorkerWithMaxRestarts limits how many times the failed worker is restarted
+// consecutively, the App is taken down once the limit is exceeded.
+func WorkerWithMaxRestarts(limit int) WorkerOption {
+	return func(w *worker) error {
+		w.maxRestarts = limit
+		return nil
+	}
+}
+
+// Run runs the worker until it returns nil, or fails without restart, or the
+// context is canceled.
+func (w *worker) Run(ctx context.Context) error {
+	backoff, restarts := w.minBackoff, 0
+	for {
+		startedAt := time.Now()
+		err := w.runOnce(ctx)
+		if err == nil || ctx.Err() != nil {
+			return nil
+		}
+
+		if time.Since(startedAt) > w.maxBackoff {
+			backoff, restarts = w.minBackoff, 0
+		}
+		if !w.restart || (w.maxRestarts > 0 && restarts >= w.maxRestarts) {
+			return fmt.Errorf("alchemy: worker %q: %w", w.name, err)
+		}
+
+		slog.Warn("Worker failed, restarting", "worker", w.name, "error", err, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff, restarts = min(2*backoff, w.maxBackoff), restarts+1
+	}
+}
+
+// runOnce runs the worker once, a panic of the worker is reported as an error.
+func (w *worker) runOnce(ctx context.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	if err = w.run(ctx); errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
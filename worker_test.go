@@ -0,0 +1,119 @@
+package alchemy_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wjiec/alchemy"
+)
+
+func TestWithWorker(t *testing.T) {
+	t.Run("canceled", func(t *testing.T) {
+		started := make(chan struct{})
+		var stopped atomic.Bool
+		app, err := alchemy.New(t.Name(),
+			alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0")),
+			alchemy.WithWorker("consumer", func(ctx context.Context) error {
+				close(started)
+				<-ctx.Done()
+				stopped.Store(true)
+				return ctx.Err()
+			}),
+		)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- app.Start(ctx) }()
+
+		<-started
+		cancel()
+		assert.NoError(t, <-done)
+		assert.True(t, stopped.Load())
+	})
+
+	t.Run("failure takes down the app", func(t *testing.T) {
+		errBroken := errors.New("broker unavailable")
+		app, err := alchemy.New(t.Name(),
+			alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0")),
+			alchemy.WithWorker("consumer", func(ctx context.Context) error {
+				return errBroken
+			}),
+		)
+		require.NoError(t, err)
+
+		err = app.Start(context.Background())
+		assert.ErrorIs(t, err, errBroken)
+		assert.ErrorContains(t, err, `worker "consumer"`)
+	})
+
+	t.Run("panic takes down the app", func(t *testing.T) {
+		app, err := alchemy.New(t.Name(),
+			alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0")),
+			alchemy.WithWorker("scheduler", func(ctx context.Context) error {
+				panic("boom")
+			}),
+		)
+		require.NoError(t, err)
+
+		assert.ErrorContains(t, app.Start(context.Background()), `worker "scheduler": panic: boom`)
+	})
+
+	t.Run("finished", func(t *testing.T) {
+		var runs atomic.Int32
+		app, err := alchemy.New(t.Name(),
+			alchemy.WithWorker("migration", func(ctx context.Context) error {
+				runs.Add(1)
+				return nil
+			}, alchemy.WorkerWithRestart(time.Millisecond, time.Millisecond)),
+		)
+		require.NoError(t, err)
+
+		assert.NoError(t, app.Start(context.Background()))
+		assert.Equal(t, int32(1), runs.Load())
+	})
+}
+
+func TestWorkerWithRestart(t *testing.T) {
+	var runs atomic.Int32
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithWorker("refresher", func(ctx context.Context) error {
+			if runs.Add(1) < 3 {
+				return errors.New("cache unavailable")
+			}
+			return nil
+		}, alchemy.WorkerWithRestart(10*time.Millisecond, 20*time.Millisecond)),
+	)
+	require.NoError(t, err)
+
+	start := time.Now()
+	assert.NoError(t, app.Start(context.Background()))
+	assert.Equal(t, int32(3), runs.Load())
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	assert.NotNil(t, alchemy.WorkerWithRestart(0, time.Second))
+	_, err = alchemy.New(t.Name(), alchemy.WithWorker("invalid", nil, alchemy.WorkerWithRestart(time.Second, 0)))
+	assert.Error(t, err)
+}
+
+func TestWorkerWithMaxRestarts(t *testing.T) {
+	var runs atomic.Int32
+	errBroken := errors.New("broker unavailable")
+	app, err := alchemy.New(t.Name(),
+		alchemy.WithHttpServer(alchemy.TCP("127.0.0.1:0")),
+		alchemy.WithWorker("consumer", func(ctx context.Context) error {
+			runs.Add(1)
+			return errBroken
+		}, alchemy.WorkerWithRestart(time.Millisecond, time.Second), alchemy.WorkerWithMaxRestarts(2)),
+	)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, app.Start(context.Background()), errBroken)
+	assert.Equal(t, int32(3), runs.Load())
+}